@@ -0,0 +1,349 @@
+package intrinio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const ARCHIVE_MAGIC string = "INTRARC1"
+const ARCHIVE_INDEX_MAGIC string = "INTRIDX1"
+const ARCHIVE_CHUNK_SIZE int = 1 << 20
+const ARCHIVE_FOOTER_SIZE int = 16
+
+var ErrInvalidArchive = errors.New("Archive - Invalid archive file")
+
+type ArchiveChunk struct {
+	Offset          int64
+	Length          uint32
+	FrameCount      uint32
+	FirstReceivedNs int64
+	LastReceivedNs  int64
+	Symbols         []string
+}
+
+type archiveIndex struct {
+	Provider  Provider
+	IsOptions bool
+	Chunks    []ArchiveChunk
+}
+
+type ArchiveQuery struct {
+	Start   time.Time
+	End     time.Time
+	Symbols []string
+}
+
+func forEachFrameSymbol(data []byte, isOptions bool, onSymbol func(string)) {
+	if len(data) == 0 {
+		return
+	}
+	count := int(data[0])
+	startIndex := 1
+	for i := 0; i < count; i++ {
+		if isOptions {
+			if startIndex+2+MAX_OPTION_SYMBOL_SIZE > len(data) {
+				return
+			}
+			msgSize := getOptionMsgSize(data[startIndex+1+MAX_OPTION_SYMBOL_SIZE])
+			contractLen := int(data[startIndex])
+			if (startIndex+msgSize > len(data)) || (contractLen > MAX_OPTION_SYMBOL_SIZE) {
+				return
+			}
+			contract := data[startIndex+1 : startIndex+1+contractLen]
+			if underlyingLen := bytes.IndexByte(contract, '_'); underlyingLen > 0 {
+				onSymbol(string(contract[:underlyingLen]))
+			}
+			startIndex += msgSize
+		} else {
+			if startIndex+3 > len(data) {
+				return
+			}
+			msgLength := int(data[startIndex+1])
+			symbolLen := int(data[startIndex+2])
+			if (msgLength < 3+symbolLen) || (startIndex+msgLength > len(data)) {
+				return
+			}
+			onSymbol(string(data[startIndex+3 : startIndex+3+symbolLen]))
+			startIndex += msgLength
+		}
+	}
+}
+
+func (query ArchiveQuery) matchTime(firstReceivedNs int64, lastReceivedNs int64) bool {
+	if !query.Start.IsZero() && (lastReceivedNs < query.Start.UnixNano()) {
+		return false
+	}
+	if !query.End.IsZero() && (firstReceivedNs >= query.End.UnixNano()) {
+		return false
+	}
+	return true
+}
+
+func (query ArchiveQuery) matchChunk(chunk ArchiveChunk) bool {
+	if !query.matchTime(chunk.FirstReceivedNs, chunk.LastReceivedNs) {
+		return false
+	}
+	if len(query.Symbols) == 0 {
+		return true
+	}
+	for _, symbol := range query.Symbols {
+		if i := sort.SearchStrings(chunk.Symbols, symbol); (i < len(chunk.Symbols)) && (chunk.Symbols[i] == symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func (query ArchiveQuery) matchFrame(frame CaptureFrame, isOptions bool) bool {
+	if !query.matchTime(frame.ReceivedNs, frame.ReceivedNs) {
+		return false
+	}
+	if len(query.Symbols) == 0 {
+		return true
+	}
+	matched := false
+	forEachFrameSymbol(frame.Data, isOptions, func(symbol string) {
+		for _, querySymbol := range query.Symbols {
+			if symbol == querySymbol {
+				matched = true
+			}
+		}
+	})
+	return matched
+}
+
+type ArchiveWriter struct {
+	lock       sync.Mutex
+	file       *os.File
+	index      archiveIndex
+	isOptions  bool
+	offset     int64
+	chunk      bytes.Buffer
+	current    ArchiveChunk
+	symbols    map[string]bool
+	frameCount uint64
+}
+
+func NewArchiveWriter(filename string, provider Provider, isOptions bool) (*ArchiveWriter, error) {
+	file, createErr := os.Create(filename)
+	if createErr != nil {
+		return nil, createErr
+	}
+	if _, writeErr := file.WriteString(ARCHIVE_MAGIC); writeErr != nil {
+		file.Close()
+		return nil, writeErr
+	}
+	return &ArchiveWriter{
+		file:      file,
+		index:     archiveIndex{Provider: provider, IsOptions: isOptions, Chunks: make([]ArchiveChunk, 0)},
+		isOptions: isOptions,
+		offset:    int64(len(ARCHIVE_MAGIC)),
+		symbols:   make(map[string]bool),
+	}, nil
+}
+
+func (archiveWriter *ArchiveWriter) WriteFrame(receivedNs int64, data []byte) error {
+	archiveWriter.lock.Lock()
+	defer archiveWriter.lock.Unlock()
+	if archiveWriter.file == nil {
+		return ErrCaptureClosed
+	}
+	if writeErr := writeCaptureFrame(&archiveWriter.chunk, receivedNs, data); writeErr != nil {
+		return writeErr
+	}
+	if archiveWriter.current.FrameCount == 0 {
+		archiveWriter.current.FirstReceivedNs = receivedNs
+	}
+	if receivedNs > archiveWriter.current.LastReceivedNs {
+		archiveWriter.current.LastReceivedNs = receivedNs
+	}
+	archiveWriter.current.FrameCount++
+	forEachFrameSymbol(data, archiveWriter.isOptions, func(symbol string) {
+		archiveWriter.symbols[symbol] = true
+	})
+	archiveWriter.frameCount++
+	if archiveWriter.chunk.Len() >= ARCHIVE_CHUNK_SIZE {
+		return archiveWriter.flushChunk()
+	}
+	return nil
+}
+
+func (archiveWriter *ArchiveWriter) flushChunk() error {
+	if archiveWriter.current.FrameCount == 0 {
+		return nil
+	}
+	var compressed bytes.Buffer
+	var header [4]byte
+	compressed.Write(header[:])
+	gzipWriter := gzip.NewWriter(&compressed)
+	if _, writeErr := gzipWriter.Write(archiveWriter.chunk.Bytes()); writeErr != nil {
+		return writeErr
+	}
+	if closeErr := gzipWriter.Close(); closeErr != nil {
+		return closeErr
+	}
+	length := compressed.Len() - len(header)
+	binary.LittleEndian.PutUint32(compressed.Bytes()[0:4], uint32(length))
+	if _, writeErr := archiveWriter.file.Write(compressed.Bytes()); writeErr != nil {
+		return writeErr
+	}
+	chunk := archiveWriter.current
+	chunk.Offset = archiveWriter.offset
+	chunk.Length = uint32(length)
+	chunk.Symbols = sortedKeys(archiveWriter.symbols)
+	archiveWriter.index.Chunks = append(archiveWriter.index.Chunks, chunk)
+	archiveWriter.offset += int64(compressed.Len())
+	archiveWriter.chunk.Reset()
+	archiveWriter.current = ArchiveChunk{}
+	archiveWriter.symbols = make(map[string]bool)
+	return nil
+}
+
+func (archiveWriter *ArchiveWriter) GetFrameCount() uint64 {
+	archiveWriter.lock.Lock()
+	defer archiveWriter.lock.Unlock()
+	return archiveWriter.frameCount
+}
+
+func (archiveWriter *ArchiveWriter) Close() error {
+	archiveWriter.lock.Lock()
+	defer archiveWriter.lock.Unlock()
+	if archiveWriter.file == nil {
+		return ErrCaptureClosed
+	}
+	flushErr := archiveWriter.flushChunk()
+	file := archiveWriter.file
+	archiveWriter.file = nil
+	if flushErr != nil {
+		file.Close()
+		return flushErr
+	}
+	index, marshalErr := json.Marshal(archiveWriter.index)
+	if marshalErr != nil {
+		file.Close()
+		return marshalErr
+	}
+	var footer [ARCHIVE_FOOTER_SIZE]byte
+	binary.LittleEndian.PutUint64(footer[0:8], uint64(archiveWriter.offset))
+	copy(footer[8:], ARCHIVE_INDEX_MAGIC)
+	if _, writeErr := file.Write(append(index, footer[:]...)); writeErr != nil {
+		file.Close()
+		return writeErr
+	}
+	return file.Close()
+}
+
+type ArchiveReader struct {
+	file  *os.File
+	index archiveIndex
+}
+
+func OpenArchive(filename string) (*ArchiveReader, error) {
+	file, openErr := os.Open(filename)
+	if openErr != nil {
+		return nil, openErr
+	}
+	index, indexErr := readArchiveIndex(file)
+	if indexErr != nil {
+		file.Close()
+		return nil, indexErr
+	}
+	return &ArchiveReader{file: file, index: index}, nil
+}
+
+func readArchiveIndex(file *os.File) (archiveIndex, error) {
+	magic := make([]byte, len(ARCHIVE_MAGIC))
+	if _, readErr := file.ReadAt(magic, 0); (readErr != nil) || (string(magic) != ARCHIVE_MAGIC) {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	info, statErr := file.Stat()
+	if statErr != nil {
+		return archiveIndex{}, statErr
+	}
+	size := info.Size()
+	if size < int64(len(ARCHIVE_MAGIC)+ARCHIVE_FOOTER_SIZE) {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	var footer [ARCHIVE_FOOTER_SIZE]byte
+	if _, readErr := file.ReadAt(footer[:], size-int64(ARCHIVE_FOOTER_SIZE)); (readErr != nil) || (string(footer[8:]) != ARCHIVE_INDEX_MAGIC) {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	indexOffset := int64(binary.LittleEndian.Uint64(footer[0:8]))
+	if (indexOffset < int64(len(ARCHIVE_MAGIC))) || (indexOffset > size-int64(ARCHIVE_FOOTER_SIZE)) {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	data := make([]byte, size-int64(ARCHIVE_FOOTER_SIZE)-indexOffset)
+	if _, readErr := file.ReadAt(data, indexOffset); readErr != nil {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	var index archiveIndex
+	if unmarshalErr := json.Unmarshal(data, &index); unmarshalErr != nil {
+		return archiveIndex{}, ErrInvalidArchive
+	}
+	return index, nil
+}
+
+func (archiveReader *ArchiveReader) GetProvider() Provider {
+	return archiveReader.index.Provider
+}
+
+func (archiveReader *ArchiveReader) GetChunks() []ArchiveChunk {
+	return archiveReader.index.Chunks
+}
+
+func (archiveReader *ArchiveReader) Scan(query ArchiveQuery, onFrame func(CaptureFrame) bool) error {
+	isOptions := archiveReader.index.IsOptions
+	for _, chunk := range archiveReader.index.Chunks {
+		if !query.matchChunk(chunk) {
+			continue
+		}
+		gzipReader, gzipErr := gzip.NewReader(io.NewSectionReader(archiveReader.file, chunk.Offset+4, int64(chunk.Length)))
+		if gzipErr != nil {
+			return ErrInvalidArchive
+		}
+		for {
+			frame, readErr := readCaptureFrame(gzipReader)
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return readErr
+			}
+			if query.matchFrame(frame, isOptions) && !onFrame(frame) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (archiveReader *ArchiveReader) Query(query ArchiveQuery) ([]CaptureFrame, error) {
+	frames := make([]CaptureFrame, 0)
+	scanErr := archiveReader.Scan(query, func(frame CaptureFrame) bool {
+		frames = append(frames, frame)
+		return true
+	})
+	return frames, scanErr
+}
+
+func (archiveReader *ArchiveReader) Close() error {
+	return archiveReader.file.Close()
+}
+
+func (client *Client) StartArchiving(filename string) error {
+	archiveWriter, createErr := NewArchiveWriter(filename, client.getConfig().Provider, client.isOptionsClient())
+	if createErr != nil {
+		return createErr
+	}
+	client.startRecording(archiveWriter)
+	client.logger.Info("Client - Archiving frames to %s\n", filename)
+	return nil
+}
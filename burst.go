@@ -0,0 +1,127 @@
+package intrinio
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type TradeBurst struct {
+	Key          string
+	IsUnderlying bool
+	TradeCount   uint32
+	Rate         float64
+	BaselineRate float64
+	Timestamp    float64
+}
+
+type TradeBurstConfig struct {
+	Window        time.Duration
+	BaselineAlpha float64
+	Factor        float64
+	MinTrades     uint32
+}
+
+var DefaultTradeBurstConfig TradeBurstConfig = TradeBurstConfig{
+	Window:        10 * time.Second,
+	BaselineAlpha: 0.1,
+	Factor:        5.0,
+	MinTrades:     10,
+}
+
+type tradeRateState struct {
+	bucketStart float64
+	count       uint32
+	baseline    float64
+	warm        bool
+	reported    bool
+}
+
+type TradeBurstDetector struct {
+	config  TradeBurstConfig
+	window  float64
+	lock    sync.Mutex
+	states  map[string]*tradeRateState
+	onBurst func(TradeBurst)
+}
+
+func NewTradeBurstDetector(config TradeBurstConfig, onBurst func(TradeBurst)) *TradeBurstDetector {
+	if config.Window <= 0 {
+		config.Window = DefaultTradeBurstConfig.Window
+	}
+	if (config.BaselineAlpha <= 0) || (config.BaselineAlpha > 1) {
+		config.BaselineAlpha = DefaultTradeBurstConfig.BaselineAlpha
+	}
+	if config.Factor <= 1 {
+		config.Factor = DefaultTradeBurstConfig.Factor
+	}
+	return &TradeBurstDetector{
+		config:  config,
+		window:  config.Window.Seconds(),
+		states:  make(map[string]*tradeRateState),
+		onBurst: onBurst,
+	}
+}
+
+func (detector *TradeBurstDetector) OnOptionTrade(trade OptionTrade) {
+	detector.observe(trade.ContractId, false, trade.Timestamp)
+	detector.observe(trade.GetUnderlyingSymbol(), true, trade.Timestamp)
+}
+
+func (detector *TradeBurstDetector) GetBaselineRate(key string) (float64, bool) {
+	detector.lock.Lock()
+	defer detector.lock.Unlock()
+	if state, ok := detector.states[key]; ok && state.warm {
+		return state.baseline, true
+	}
+	return 0.0, false
+}
+
+func (detector *TradeBurstDetector) observe(key string, isUnderlying bool, timestamp float64) {
+	detector.lock.Lock()
+	state, ok := detector.states[key]
+	if !ok {
+		state = &tradeRateState{bucketStart: timestamp}
+		detector.states[key] = state
+	}
+	if elapsed := timestamp - state.bucketStart; elapsed >= detector.window {
+		buckets := math.Floor(elapsed / detector.window)
+		rate := float64(state.count) / detector.window
+		if state.warm {
+			state.baseline = detector.config.BaselineAlpha*rate + (1.0-detector.config.BaselineAlpha)*state.baseline
+		} else {
+			state.baseline = rate
+			state.warm = true
+		}
+		state.baseline = state.baseline * math.Pow(1.0-detector.config.BaselineAlpha, buckets-1)
+		state.bucketStart = state.bucketStart + buckets*detector.window
+		state.count = 0
+		state.reported = false
+	}
+	state.count++
+	var burst *TradeBurst = nil
+	if state.warm && !state.reported && (state.count >= detector.config.MinTrades) {
+		rate := float64(state.count) / detector.window
+		if rate >= detector.config.Factor*state.baseline {
+			state.reported = true
+			burst = &TradeBurst{
+				Key:          key,
+				IsUnderlying: isUnderlying,
+				TradeCount:   state.count,
+				Rate:         rate,
+				BaselineRate: state.baseline,
+				Timestamp:    timestamp,
+			}
+		}
+	}
+	detector.lock.Unlock()
+	if (burst != nil) && (detector.onBurst != nil) {
+		detector.onBurst(*burst)
+	}
+}
+
+func (detector *TradeBurstDetector) ResetSession() {
+	detector.lock.Lock()
+	detector.states = make(map[string]*tradeRateState)
+	detector.lock.Unlock()
+}
@@ -0,0 +1,136 @@
+package intrinio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type BurstWindow struct {
+	StartHour   int
+	StartMinute int
+	EndHour     int
+	EndMinute   int
+}
+
+type BurstModeConfig struct {
+	Windows       []BurstWindow
+	Location      *time.Location
+	RateThreshold float64
+	ExtraWorkers  int
+}
+
+var DefaultBurstModeConfig BurstModeConfig = BurstModeConfig{
+	Windows: []BurstWindow{
+		{StartHour: 9, StartMinute: 25, EndHour: 9, EndMinute: 45},
+		{StartHour: 15, StartMinute: 50, EndHour: 16, EndMinute: 10},
+	},
+	RateThreshold: 0,
+	ExtraWorkers:  4,
+}
+
+type BurstModeChange struct {
+	Active bool
+	Reason string
+	Time   time.Time
+}
+
+type burstMode struct {
+	config   BurstModeConfig
+	onChange func(BurstModeChange)
+	isActive uint32
+	done     chan bool
+}
+
+func (window BurstWindow) contains(now time.Time) bool {
+	minutes := now.Hour()*60 + now.Minute()
+	return (minutes >= window.StartHour*60+window.StartMinute) && (minutes < window.EndHour*60+window.EndMinute)
+}
+
+func (client *Client) SetBurstMode(config BurstModeConfig, onChange func(BurstModeChange)) {
+	if config.Location == nil {
+		config.Location = loadMarketLocation()
+	}
+	if config.ExtraWorkers < 0 {
+		config.ExtraWorkers = 0
+	}
+	client.burstMode = &burstMode{
+		config:   config,
+		onChange: onChange,
+	}
+}
+
+func (client *Client) IsBurstMode() bool {
+	return (client.burstMode != nil) && (atomic.LoadUint32(&client.burstMode.isActive) == 1)
+}
+
+func (client *Client) getBurstModeReason(now time.Time, rate float64) string {
+	local := now.In(client.burstMode.config.Location)
+	for _, window := range client.burstMode.config.Windows {
+		if window.contains(local) {
+			return "window"
+		}
+	}
+	threshold := client.burstMode.config.RateThreshold
+	if threshold > 0 {
+		if rate >= threshold {
+			return "rate"
+		}
+		if client.IsBurstMode() && (rate >= threshold/2) {
+			return "rate"
+		}
+	}
+	return ""
+}
+
+func (client *Client) setBurstModeActive(active bool, reason string, now time.Time) {
+	mode := client.burstMode
+	if active {
+		if !atomic.CompareAndSwapUint32(&mode.isActive, 0, 1) {
+			return
+		}
+		mode.done = make(chan bool)
+		for i := 0; i < mode.config.ExtraWorkers; i++ {
+			go client.runBurstWorker(mode.done)
+		}
+		client.logger.Info("Client - Entering burst mode (%s, %d extra workers)\n", reason, mode.config.ExtraWorkers)
+	} else {
+		if !atomic.CompareAndSwapUint32(&mode.isActive, 1, 0) {
+			return
+		}
+		close(mode.done)
+		client.logger.Info("Client - Leaving burst mode")
+	}
+	if mode.onChange != nil {
+		mode.onChange(BurstModeChange{Active: active, Reason: reason, Time: now})
+	}
+}
+
+func (client *Client) runBurstWorker(done chan bool) {
+	for {
+		select {
+		case <-done:
+			return
+		case data := <-client.readChannel:
+			client.process(data)
+		}
+	}
+}
+
+func (client *Client) runBurstMode() {
+	previousCount := atomic.LoadUint64(&client.dataMsgCount)
+	previousTime := time.Now()
+	for !client.isStopped {
+		time.Sleep(time.Second)
+		now := time.Now()
+		count := atomic.LoadUint64(&client.dataMsgCount)
+		rate := float64(count-previousCount) / now.Sub(previousTime).Seconds()
+		previousCount = count
+		previousTime = now
+		if reason := client.getBurstModeReason(now, rate); reason != "" {
+			client.setBurstModeActive(true, reason, now)
+		} else {
+			client.setBurstModeActive(false, "", now)
+		}
+	}
+	client.setBurstModeActive(false, "stopped", time.Now())
+}
@@ -0,0 +1,430 @@
+package intrinio
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type TradeCandleStick struct {
+	Symbol         string
+	Interval       time.Duration
+	Volume         uint64
+	TradeCount     uint32
+	Notional       float64
+	High           float64
+	Low            float64
+	Close          float64
+	Open           float64
+	OpenTimestamp  float64
+	CloseTimestamp float64
+	FirstTimestamp float64
+	LastTimestamp  float64
+	Complete       bool
+	Average        float64
+	Change         float64
+}
+
+type QuoteCandleStick struct {
+	Symbol         string
+	Interval       time.Duration
+	QuoteType      QuoteType
+	Size           uint64
+	UpdateCount    uint32
+	High           float64
+	Low            float64
+	Close          float64
+	Open           float64
+	OpenTimestamp  float64
+	CloseTimestamp float64
+	FirstTimestamp float64
+	LastTimestamp  float64
+	Complete       bool
+	Average        float64
+	Change         float64
+}
+
+const OPTION_CONTRACT_MULTIPLIER float64 = 100.0
+
+type CandleAlignment uint8
+
+const (
+	CANDLE_ALIGN_UTC      CandleAlignment = 0
+	CANDLE_ALIGN_EXCHANGE CandleAlignment = 1
+)
+
+type CandleStickConfig struct {
+	Intervals          []time.Duration
+	EmitIncomplete     bool
+	FlushDelay         time.Duration
+	Alignment          CandleAlignment
+	Location           *time.Location
+	AlignmentOffset    time.Duration
+	SessionLength      time.Duration
+	ContractMultiplier func(contractId string) float64
+}
+
+var DefaultCandleStickConfig CandleStickConfig = CandleStickConfig{
+	Intervals:      []time.Duration{time.Minute},
+	EmitIncomplete: false,
+	FlushDelay:     time.Second,
+}
+
+type tradeCandleKey struct {
+	symbol   string
+	interval time.Duration
+}
+
+type quoteCandleKey struct {
+	symbol    string
+	quoteType QuoteType
+	interval  time.Duration
+}
+
+type CandleStickClient struct {
+	config        CandleStickConfig
+	lock          sync.Mutex
+	tradeCandles  map[tradeCandleKey]*TradeCandleStick
+	quoteCandles  map[quoteCandleKey]*QuoteCandleStick
+	onTradeCandle func(TradeCandleStick)
+	onQuoteCandle func(QuoteCandleStick)
+	done          chan bool
+}
+
+func NewCandleStickClient(
+	config CandleStickConfig,
+	onTradeCandle func(TradeCandleStick),
+	onQuoteCandle func(QuoteCandleStick)) *CandleStickClient {
+	intervals := make([]time.Duration, 0, len(config.Intervals))
+	for _, interval := range config.Intervals {
+		if interval > 0 {
+			intervals = append(intervals, interval)
+		}
+	}
+	if len(intervals) == 0 {
+		intervals = DefaultCandleStickConfig.Intervals
+	}
+	config.Intervals = intervals
+	if config.FlushDelay < 0 {
+		config.FlushDelay = DefaultCandleStickConfig.FlushDelay
+	}
+	if (config.Alignment == CANDLE_ALIGN_EXCHANGE) && (config.Location == nil) {
+		config.Location = loadMarketLocation()
+	}
+	if config.SessionLength < 0 {
+		config.SessionLength = 0
+	}
+	return &CandleStickClient{
+		config:        config,
+		tradeCandles:  make(map[tradeCandleKey]*TradeCandleStick),
+		quoteCandles:  make(map[quoteCandleKey]*QuoteCandleStick),
+		onTradeCandle: onTradeCandle,
+		onQuoteCandle: onQuoteCandle,
+	}
+}
+
+func (candleClient *CandleStickClient) getSessionStart(timestamp float64) float64 {
+	offset := candleClient.config.AlignmentOffset.Seconds()
+	if candleClient.config.Alignment == CANDLE_ALIGN_EXCHANGE {
+		local := time.Unix(0, int64(timestamp*1_000_000_000.0)).In(candleClient.config.Location)
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, candleClient.config.Location)
+		return float64(midnight.Unix()) + offset
+	}
+	return math.Floor(timestamp/86400.0)*86400.0 + offset
+}
+
+func (candleClient *CandleStickClient) getOpenTimestamp(timestamp float64, interval time.Duration) float64 {
+	seconds := interval.Seconds()
+	anchor := candleClient.config.AlignmentOffset.Seconds()
+	if (candleClient.config.Alignment == CANDLE_ALIGN_EXCHANGE) || (candleClient.config.SessionLength > 0) {
+		anchor = candleClient.getSessionStart(timestamp)
+		if sessionEnd := anchor + candleClient.config.SessionLength.Seconds(); (candleClient.config.SessionLength > 0) && (timestamp >= sessionEnd) {
+			anchor = sessionEnd
+		}
+	}
+	return math.Floor((timestamp-anchor)/seconds)*seconds + anchor
+}
+
+func (candleClient *CandleStickClient) getCloseTimestamp(openTimestamp float64, interval time.Duration) float64 {
+	closeTimestamp := openTimestamp + interval.Seconds()
+	if candleClient.config.SessionLength > 0 {
+		sessionStart := candleClient.getSessionStart(openTimestamp)
+		sessionEnd := sessionStart + candleClient.config.SessionLength.Seconds()
+		if (openTimestamp >= sessionStart) && (openTimestamp < sessionEnd) && (closeTimestamp > sessionEnd) {
+			return sessionEnd
+		}
+	}
+	return closeTimestamp
+}
+
+func getCandleChange(open float64, close float64) float64 {
+	if open == 0.0 {
+		return 0.0
+	}
+	return (close - open) / open
+}
+
+func (candleClient *CandleStickClient) OnEquityTrade(trade EquityTrade) {
+	candleClient.addTrade(trade.Symbol, float64(trade.Price), uint64(trade.Size), 1.0, trade.Timestamp)
+}
+
+func (candleClient *CandleStickClient) OnEquityQuote(quote EquityQuote) {
+	candleClient.addQuote(quote.Symbol, quote.Type, float64(quote.Price), uint64(quote.Size), quote.Timestamp)
+}
+
+func (candleClient *CandleStickClient) getContractMultiplier(contractId string) float64 {
+	if candleClient.config.ContractMultiplier == nil {
+		return OPTION_CONTRACT_MULTIPLIER
+	}
+	if multiplier := candleClient.config.ContractMultiplier(contractId); multiplier > 0.0 {
+		return multiplier
+	}
+	return OPTION_CONTRACT_MULTIPLIER
+}
+
+func (candleClient *CandleStickClient) OnOptionTrade(trade OptionTrade) {
+	candleClient.addTrade(trade.ContractId, float64(trade.Price), uint64(trade.Size), candleClient.getContractMultiplier(trade.ContractId), trade.Timestamp)
+}
+
+func (candleClient *CandleStickClient) OnOptionQuote(quote OptionQuote) {
+	candleClient.addQuote(quote.ContractId, ASK, float64(quote.AskPrice), uint64(quote.AskSize), quote.Timestamp)
+	candleClient.addQuote(quote.ContractId, BID, float64(quote.BidPrice), uint64(quote.BidSize), quote.Timestamp)
+}
+
+func (candleClient *CandleStickClient) addTrade(symbol string, price float64, size uint64, multiplier float64, timestamp float64) {
+	if (price <= 0.0) || (size == 0) {
+		return
+	}
+	completed := make([]TradeCandleStick, 0)
+	updated := make([]TradeCandleStick, 0, len(candleClient.config.Intervals))
+	candleClient.lock.Lock()
+	for _, interval := range candleClient.config.Intervals {
+		key := tradeCandleKey{symbol: symbol, interval: interval}
+		openTimestamp := candleClient.getOpenTimestamp(timestamp, interval)
+		candle, ok := candleClient.tradeCandles[key]
+		if ok && (openTimestamp < candle.OpenTimestamp) {
+			continue
+		}
+		if ok && (openTimestamp > candle.OpenTimestamp) {
+			candle.Complete = true
+			completed = append(completed, *candle)
+			ok = false
+		}
+		if !ok {
+			candle = &TradeCandleStick{
+				Symbol:         symbol,
+				Interval:       interval,
+				High:           price,
+				Low:            price,
+				Open:           price,
+				OpenTimestamp:  openTimestamp,
+				CloseTimestamp: candleClient.getCloseTimestamp(openTimestamp, interval),
+				FirstTimestamp: timestamp,
+			}
+			candleClient.tradeCandles[key] = candle
+		}
+		candle.Average = ((candle.Average * float64(candle.Volume)) + (price * float64(size))) / float64(candle.Volume+size)
+		candle.Volume += size
+		candle.Notional += price * float64(size) * multiplier
+		candle.TradeCount++
+		candle.High = math.Max(candle.High, price)
+		candle.Low = math.Min(candle.Low, price)
+		candle.Close = price
+		candle.LastTimestamp = math.Max(candle.LastTimestamp, timestamp)
+		candle.Change = getCandleChange(candle.Open, candle.Close)
+		updated = append(updated, *candle)
+	}
+	candleClient.lock.Unlock()
+	candleClient.publishTradeCandles(completed, updated)
+}
+
+func (candleClient *CandleStickClient) addQuote(symbol string, quoteType QuoteType, price float64, size uint64, timestamp float64) {
+	if price <= 0.0 {
+		return
+	}
+	completed := make([]QuoteCandleStick, 0)
+	updated := make([]QuoteCandleStick, 0, len(candleClient.config.Intervals))
+	candleClient.lock.Lock()
+	for _, interval := range candleClient.config.Intervals {
+		key := quoteCandleKey{symbol: symbol, quoteType: quoteType, interval: interval}
+		openTimestamp := candleClient.getOpenTimestamp(timestamp, interval)
+		candle, ok := candleClient.quoteCandles[key]
+		if ok && (openTimestamp < candle.OpenTimestamp) {
+			continue
+		}
+		if ok && (openTimestamp > candle.OpenTimestamp) {
+			candle.Complete = true
+			completed = append(completed, *candle)
+			ok = false
+		}
+		if !ok {
+			candle = &QuoteCandleStick{
+				Symbol:         symbol,
+				Interval:       interval,
+				QuoteType:      quoteType,
+				High:           price,
+				Low:            price,
+				Open:           price,
+				OpenTimestamp:  openTimestamp,
+				CloseTimestamp: candleClient.getCloseTimestamp(openTimestamp, interval),
+				FirstTimestamp: timestamp,
+			}
+			candleClient.quoteCandles[key] = candle
+		}
+		if candle.Size+size > 0 {
+			candle.Average = ((candle.Average * float64(candle.Size)) + (price * float64(size))) / float64(candle.Size+size)
+		} else {
+			candle.Average = price
+		}
+		candle.Size += size
+		candle.UpdateCount++
+		candle.High = math.Max(candle.High, price)
+		candle.Low = math.Min(candle.Low, price)
+		candle.Close = price
+		candle.LastTimestamp = math.Max(candle.LastTimestamp, timestamp)
+		candle.Change = getCandleChange(candle.Open, candle.Close)
+		updated = append(updated, *candle)
+	}
+	candleClient.lock.Unlock()
+	candleClient.publishQuoteCandles(completed, updated)
+}
+
+func (candleClient *CandleStickClient) publishTradeCandles(completed []TradeCandleStick, updated []TradeCandleStick) {
+	if candleClient.onTradeCandle == nil {
+		return
+	}
+	for _, candle := range completed {
+		candleClient.onTradeCandle(candle)
+	}
+	if candleClient.config.EmitIncomplete {
+		for _, candle := range updated {
+			candleClient.onTradeCandle(candle)
+		}
+	}
+}
+
+func (candleClient *CandleStickClient) publishQuoteCandles(completed []QuoteCandleStick, updated []QuoteCandleStick) {
+	if candleClient.onQuoteCandle == nil {
+		return
+	}
+	for _, candle := range completed {
+		candleClient.onQuoteCandle(candle)
+	}
+	if candleClient.config.EmitIncomplete {
+		for _, candle := range updated {
+			candleClient.onQuoteCandle(candle)
+		}
+	}
+}
+
+func sortTradeCandles(candles []TradeCandleStick) {
+	sort.Slice(candles, func(i, j int) bool {
+		if candles[i].OpenTimestamp != candles[j].OpenTimestamp {
+			return candles[i].OpenTimestamp < candles[j].OpenTimestamp
+		}
+		if candles[i].Symbol != candles[j].Symbol {
+			return candles[i].Symbol < candles[j].Symbol
+		}
+		return candles[i].Interval < candles[j].Interval
+	})
+}
+
+func sortQuoteCandles(candles []QuoteCandleStick) {
+	sort.Slice(candles, func(i, j int) bool {
+		if candles[i].OpenTimestamp != candles[j].OpenTimestamp {
+			return candles[i].OpenTimestamp < candles[j].OpenTimestamp
+		}
+		if candles[i].Symbol != candles[j].Symbol {
+			return candles[i].Symbol < candles[j].Symbol
+		}
+		if candles[i].Interval != candles[j].Interval {
+			return candles[i].Interval < candles[j].Interval
+		}
+		return candles[i].QuoteType < candles[j].QuoteType
+	})
+}
+
+func (candleClient *CandleStickClient) Flush(timestamp float64) {
+	cutoff := timestamp - candleClient.config.FlushDelay.Seconds()
+	tradeCandles := make([]TradeCandleStick, 0)
+	quoteCandles := make([]QuoteCandleStick, 0)
+	candleClient.lock.Lock()
+	for key, candle := range candleClient.tradeCandles {
+		if candle.CloseTimestamp <= cutoff {
+			candle.Complete = true
+			tradeCandles = append(tradeCandles, *candle)
+			delete(candleClient.tradeCandles, key)
+		}
+	}
+	for key, candle := range candleClient.quoteCandles {
+		if candle.CloseTimestamp <= cutoff {
+			candle.Complete = true
+			quoteCandles = append(quoteCandles, *candle)
+			delete(candleClient.quoteCandles, key)
+		}
+	}
+	candleClient.lock.Unlock()
+	sortTradeCandles(tradeCandles)
+	sortQuoteCandles(quoteCandles)
+	candleClient.publishTradeCandles(tradeCandles, nil)
+	candleClient.publishQuoteCandles(quoteCandles, nil)
+}
+
+func (candleClient *CandleStickClient) GetTradeCandleStick(symbol string, interval time.Duration) (TradeCandleStick, bool) {
+	candleClient.lock.Lock()
+	defer candleClient.lock.Unlock()
+	if candle, ok := candleClient.tradeCandles[tradeCandleKey{symbol: symbol, interval: interval}]; ok {
+		return *candle, true
+	}
+	return TradeCandleStick{}, false
+}
+
+func (candleClient *CandleStickClient) GetQuoteCandleStick(symbol string, quoteType QuoteType, interval time.Duration) (QuoteCandleStick, bool) {
+	candleClient.lock.Lock()
+	defer candleClient.lock.Unlock()
+	if candle, ok := candleClient.quoteCandles[quoteCandleKey{symbol: symbol, quoteType: quoteType, interval: interval}]; ok {
+		return *candle, true
+	}
+	return QuoteCandleStick{}, false
+}
+
+func (candleClient *CandleStickClient) Start() {
+	candleClient.lock.Lock()
+	if candleClient.done != nil {
+		candleClient.lock.Unlock()
+		return
+	}
+	done := make(chan bool)
+	candleClient.done = done
+	candleClient.lock.Unlock()
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				done <- true
+				return
+			case now := <-ticker.C:
+				candleClient.Flush(float64(now.UnixNano()) / 1_000_000_000.0)
+			}
+		}
+	}()
+}
+
+func (candleClient *CandleStickClient) Stop() {
+	candleClient.lock.Lock()
+	done := candleClient.done
+	candleClient.done = nil
+	candleClient.lock.Unlock()
+	if done != nil {
+		done <- true
+		<-done
+	}
+	candleClient.Flush(math.Inf(1))
+}
+
+func (candleClient *CandleStickClient) ResetSession() {
+	candleClient.Flush(math.Inf(1))
+}
@@ -0,0 +1,195 @@
+package intrinio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const CAPTURE_MAGIC string = "INTRCAP1"
+const CAPTURE_RECORD_HEADER_SIZE int = 12
+
+var ErrInvalidCapture = errors.New("Capture - Invalid capture file")
+var ErrCaptureClosed = errors.New("Capture - Capture file is closed")
+
+type CaptureFrame struct {
+	ReceivedNs int64
+	Data       []byte
+}
+
+func readCaptureHeader(reader io.Reader) error {
+	magic := make([]byte, len(CAPTURE_MAGIC))
+	if _, readErr := io.ReadFull(reader, magic); readErr != nil {
+		return ErrInvalidCapture
+	}
+	if string(magic) != CAPTURE_MAGIC {
+		return ErrInvalidCapture
+	}
+	return nil
+}
+
+func readCaptureFrame(reader io.Reader) (CaptureFrame, error) {
+	var header [CAPTURE_RECORD_HEADER_SIZE]byte
+	if _, readErr := io.ReadFull(reader, header[:]); readErr != nil {
+		if readErr == io.ErrUnexpectedEOF {
+			return CaptureFrame{}, ErrInvalidCapture
+		}
+		return CaptureFrame{}, readErr
+	}
+	length := binary.LittleEndian.Uint32(header[8:12])
+	if int64(length) > MAX_FRAME_SIZE {
+		return CaptureFrame{}, ErrInvalidCapture
+	}
+	data := make([]byte, length)
+	if _, readErr := io.ReadFull(reader, data); readErr != nil {
+		return CaptureFrame{}, ErrInvalidCapture
+	}
+	return CaptureFrame{
+		ReceivedNs: int64(binary.LittleEndian.Uint64(header[0:8])),
+		Data:       data,
+	}, nil
+}
+
+func writeCaptureFrame(writer io.Writer, receivedNs int64, data []byte) error {
+	var header [CAPTURE_RECORD_HEADER_SIZE]byte
+	binary.LittleEndian.PutUint64(header[0:8], uint64(receivedNs))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(len(data)))
+	if _, writeErr := writer.Write(header[:]); writeErr != nil {
+		return writeErr
+	}
+	_, writeErr := writer.Write(data)
+	return writeErr
+}
+
+type frameRecorder interface {
+	WriteFrame(receivedNs int64, data []byte) error
+	GetFrameCount() uint64
+	Close() error
+}
+
+type recording struct {
+	recorder frameRecorder
+}
+
+type CaptureWriter struct {
+	lock       sync.Mutex
+	file       *os.File
+	writer     *bufio.Writer
+	frameCount uint64
+}
+
+func NewCaptureWriter(filename string) (*CaptureWriter, error) {
+	file, createErr := os.Create(filename)
+	if createErr != nil {
+		return nil, createErr
+	}
+	writer := bufio.NewWriterSize(file, FRAME_BUFFER_SIZE*4)
+	if _, writeErr := writer.WriteString(CAPTURE_MAGIC); writeErr != nil {
+		file.Close()
+		return nil, writeErr
+	}
+	return &CaptureWriter{file: file, writer: writer}, nil
+}
+
+func (captureWriter *CaptureWriter) WriteFrame(receivedNs int64, data []byte) error {
+	captureWriter.lock.Lock()
+	defer captureWriter.lock.Unlock()
+	if captureWriter.writer == nil {
+		return ErrCaptureClosed
+	}
+	if writeErr := writeCaptureFrame(captureWriter.writer, receivedNs, data); writeErr != nil {
+		return writeErr
+	}
+	captureWriter.frameCount++
+	return nil
+}
+
+func (captureWriter *CaptureWriter) GetFrameCount() uint64 {
+	captureWriter.lock.Lock()
+	defer captureWriter.lock.Unlock()
+	return captureWriter.frameCount
+}
+
+func (captureWriter *CaptureWriter) Close() error {
+	captureWriter.lock.Lock()
+	defer captureWriter.lock.Unlock()
+	if captureWriter.writer == nil {
+		return ErrCaptureClosed
+	}
+	flushErr := captureWriter.writer.Flush()
+	closeErr := captureWriter.file.Close()
+	captureWriter.writer = nil
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+func ReadCaptureFile(filename string) ([]CaptureFrame, error) {
+	file, openErr := os.Open(filename)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	if headerErr := readCaptureHeader(reader); headerErr != nil {
+		return nil, headerErr
+	}
+	frames := make([]CaptureFrame, 0)
+	for {
+		frame, readErr := readCaptureFrame(reader)
+		if readErr == io.EOF {
+			return frames, nil
+		}
+		if readErr != nil {
+			return frames, readErr
+		}
+		frames = append(frames, frame)
+	}
+}
+
+func (client *Client) StartRecording(filename string) error {
+	captureWriter, createErr := NewCaptureWriter(filename)
+	if createErr != nil {
+		return createErr
+	}
+	client.startRecording(captureWriter)
+	client.logger.Info("Client - Recording frames to %s\n", filename)
+	return nil
+}
+
+func (client *Client) startRecording(recorder frameRecorder) {
+	client.recorderLock.Lock()
+	previous, _ := client.recorder.Load().(recording)
+	client.recorder.Store(recording{recorder: recorder})
+	client.recorderLock.Unlock()
+	if previous.recorder != nil {
+		if closeErr := previous.recorder.Close(); closeErr != nil {
+			client.logger.Warn("Client - Failure to close previous recording: %v\n", closeErr)
+		}
+	}
+}
+
+func (client *Client) StopRecording() error {
+	client.recorderLock.Lock()
+	previous, _ := client.recorder.Load().(recording)
+	client.recorder.Store(recording{})
+	client.recorderLock.Unlock()
+	if previous.recorder == nil {
+		return nil
+	}
+	client.logger.Info("Client - Recorded %d frames\n", previous.recorder.GetFrameCount())
+	return previous.recorder.Close()
+}
+
+func (client *Client) record(data []byte) {
+	if active, _ := client.recorder.Load().(recording); active.recorder != nil {
+		if recordErr := active.recorder.WriteFrame(time.Now().UnixNano(), data); recordErr != nil {
+			client.logger.Warn("Client - Failure to record frame: %v\n", recordErr)
+		}
+	}
+}
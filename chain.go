@@ -0,0 +1,161 @@
+package intrinio
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const CHAIN_GROUP_PREFIX string = "$CHAIN:"
+const CHAIN_REFRESH_INTERVAL time.Duration = time.Hour
+const CHAIN_PAGE_SIZE string = "10000"
+
+var ErrNotOptionsClient = errors.New("Client - Option chains may only be joined on an options client")
+
+type ChainFilter struct {
+	MinExpiration time.Time
+	MaxExpiration time.Time
+	MinStrike     float64
+	MaxStrike     float64
+	PutsOnly      bool
+	CallsOnly     bool
+}
+
+type restOptionContract struct {
+	Code       string  `json:"code"`
+	Expiration string  `json:"expiration"`
+	Strike     float64 `json:"strike"`
+	Type       string  `json:"type"`
+}
+
+type restOptionContracts struct {
+	Options  []restOptionContract `json:"options"`
+	NextPage string               `json:"next_page"`
+}
+
+func getChainGroup(underlying string) string {
+	return CHAIN_GROUP_PREFIX + underlying
+}
+
+func getContractId(code string) string {
+	if len(code) <= 15 {
+		return code
+	}
+	symbol := code[:len(code)-15]
+	if len(symbol) < 6 {
+		symbol = symbol + strings.Repeat("_", 6-len(symbol))
+	}
+	return symbol + code[len(code)-15:]
+}
+
+func (filter ChainFilter) getQuery() url.Values {
+	query := url.Values{"page_size": {CHAIN_PAGE_SIZE}}
+	if filter.PutsOnly && !filter.CallsOnly {
+		query.Set("type", "put")
+	} else if filter.CallsOnly && !filter.PutsOnly {
+		query.Set("type", "call")
+	}
+	if !filter.MinExpiration.IsZero() {
+		query.Set("expiration_after", filter.MinExpiration.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	if !filter.MaxExpiration.IsZero() {
+		query.Set("expiration_before", filter.MaxExpiration.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+	return query
+}
+
+func (filter ChainFilter) accept(contract restOptionContract) bool {
+	contractType := strings.ToLower(contract.Type)
+	if (filter.PutsOnly && !filter.CallsOnly && (contractType != "put")) ||
+		(filter.CallsOnly && !filter.PutsOnly && (contractType != "call")) {
+		return false
+	}
+	if (filter.MinStrike > 0) && (contract.Strike < filter.MinStrike) {
+		return false
+	}
+	if (filter.MaxStrike > 0) && (contract.Strike > filter.MaxStrike) {
+		return false
+	}
+	expiration := contract.Expiration
+	if !filter.MinExpiration.IsZero() && (expiration < filter.MinExpiration.Format("2006-01-02")) {
+		return false
+	}
+	if !filter.MaxExpiration.IsZero() && (expiration > filter.MaxExpiration.Format("2006-01-02")) {
+		return false
+	}
+	return true
+}
+
+func (client *Client) getChainContracts(underlying string, filter ChainFilter) ([]string, error) {
+	query := filter.getQuery()
+	contracts := make([]string, 0)
+	for {
+		var page restOptionContracts
+		fetchErr := client.rest.getJSON("/options/"+url.PathEscape(underlying), query, &page)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		for _, contract := range page.Options {
+			if filter.accept(contract) {
+				contracts = append(contracts, getContractId(contract.Code))
+			}
+		}
+		if page.NextPage == "" {
+			return contracts, nil
+		}
+		query.Set("next_page", page.NextPage)
+	}
+}
+
+func (client *Client) refreshOptionChain(underlying string, filter ChainFilter) error {
+	contracts, fetchErr := client.getChainContracts(underlying, filter)
+	if fetchErr != nil {
+		return fetchErr
+	}
+	client.SwapGroup(getChainGroup(underlying), contracts)
+	return nil
+}
+
+func (client *Client) JoinOptionChain(underlying string, filter ChainFilter) error {
+	if !client.isOptionsClient() {
+		return ErrNotOptionsClient
+	}
+	underlying = strings.TrimSpace(underlying)
+	if refreshErr := client.refreshOptionChain(underlying, filter); refreshErr != nil {
+		client.logger.Error("Client - Failure to resolve option chain %s: %v\n", underlying, refreshErr)
+		return refreshErr
+	}
+	client.chainsLock.Lock()
+	client.chains[underlying] = filter
+	client.chainsLock.Unlock()
+	return nil
+}
+
+func (client *Client) LeaveOptionChain(underlying string) {
+	underlying = strings.TrimSpace(underlying)
+	client.chainsLock.Lock()
+	delete(client.chains, underlying)
+	client.chainsLock.Unlock()
+	client.LeaveGroup(getChainGroup(underlying))
+}
+
+func (client *Client) refreshOptionChains() {
+	for !client.isStopped {
+		time.Sleep(CHAIN_REFRESH_INTERVAL)
+		client.chainsLock.Lock()
+		chains := make(map[string]ChainFilter, len(client.chains))
+		for underlying, filter := range client.chains {
+			chains[underlying] = filter
+		}
+		client.chainsLock.Unlock()
+		for _, underlying := range sortedKeys(chains) {
+			if client.isStopped {
+				return
+			}
+			if refreshErr := client.refreshOptionChain(underlying, chains[underlying]); refreshErr != nil {
+				client.logger.Warn("Client - Failure to refresh option chain %s: %v\n", underlying, refreshErr)
+			}
+		}
+	}
+}
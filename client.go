@@ -1,12 +1,16 @@
 package intrinio
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -15,11 +19,20 @@ import (
 var selfHealBackoffs [5]int = [5]int{10, 30, 60, 300, 600}
 
 const (
-	HEARTBEAT_INTERVAL       int = 20
-	MAX_OPTIONS_QUEUE_DEPTH  int = 20000
-	MAX_EQUITIES_QUEUE_DEPTH int = 10000
+	HEARTBEAT_INTERVAL       int   = 20
+	MAX_OPTIONS_QUEUE_DEPTH  int   = 20000
+	MAX_EQUITIES_QUEUE_DEPTH int   = 10000
+	WRITE_QUEUE_DEPTH        int   = 1000
+	WS_READ_BUFFER_SIZE      int   = 10240
+	WS_WRITE_BUFFER_SIZE     int   = 128
+	MAX_FRAME_SIZE           int64 = 1 << 20
+	FRAME_BUFFER_SIZE        int   = 1 << 14
 )
 
+const LOBBY_CHANNEL string = "$FIREHOSE"
+
+var ErrInvalidFrame = errors.New("Client - Invalid frame")
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -27,6 +40,15 @@ func min(a, b int) int {
 	return b
 }
 
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func doBackoff(fn func() bool, isStopped *bool) {
 	i := 0
 	backoff := selfHealBackoffs[i]
@@ -41,26 +63,100 @@ func doBackoff(fn func() bool, isStopped *bool) {
 	}
 }
 
+type SubscriptionOptions struct {
+	Trades          bool
+	Quotes          bool
+	Refreshes       bool
+	UnusualActivity bool
+}
+
+var DefaultSubscriptionOptions SubscriptionOptions = SubscriptionOptions{
+	Trades:          true,
+	Quotes:          true,
+	Refreshes:       true,
+	UnusualActivity: true,
+}
+
+func getSubscriptionOptions(tradesOnly bool) SubscriptionOptions {
+	options := DefaultSubscriptionOptions
+	options.Quotes = !tradesOnly
+	return options
+}
+
 type Client struct {
-	token           string
-	tokenUpdateTime time.Time
-	dataMsgCount    uint64
-	txtMsgCount     uint32
-	workerCount     int
-	subscriptions   map[string]bool
-	isStopped       bool
-	isClosed        bool
-	closeWg         sync.WaitGroup
-	reconnected     chan bool
-	readChannel     chan []byte
-	writeChannel    chan []byte
-	httpClient      *http.Client
-	wsConn          *websocket.Conn
-	heartbeat       *time.Ticker
-	config          Config
-	work            func()
-	composeJoinMsg  func(string) []byte
-	composeLeaveMsg func(string) []byte
+	token               string
+	tokenUpdateTime     time.Time
+	dataMsgCount        uint64
+	txtMsgCount         uint32
+	droppedFrameCount   uint64
+	droppedEventCount   uint64
+	bytesReceived       uint64
+	lastMessageTime     int64
+	lastResponseTime    int64
+	keepAlive           atomic.Value
+	heartbeatInterval   int64
+	staleTimeout        int64
+	staleCount          uint64
+	queueHighWatermark  int64
+	reconnectCount      uint64
+	eventCounts         [eventKindCount]uint64
+	subProviderCounts   [SUB_PROVIDER_COUNT]uint64
+	disabledSubProvider [SUB_PROVIDER_COUNT]uint32
+	workerCount         int
+	subscriptions       map[string]SubscriptionOptions
+	subscriptionsLock   sync.Mutex
+	subscriptionGuard   atomic.Value
+	guardTripped        bool
+	blockedJoinCount    uint64
+	stateStore          StateStore
+	stateDirty          uint32
+	stateFrozen         uint32
+	savedSubscriptions  map[string]SubscriptionOptions
+	symbols             *symbolTable
+	directJoins         map[string]bool
+	failedSubscriptions map[string]string
+	onSubscriptionError func(SubscriptionError)
+	onAuthFailure       func(AuthFailure)
+	groups              map[string]map[string]bool
+	chains              map[string]ChainFilter
+	chainsLock          sync.Mutex
+	isStopped           bool
+	isClosed            bool
+	closeWg             sync.WaitGroup
+	reconnected         chan bool
+	readChannel         chan []byte
+	framePool           sync.Pool
+	writeChannel        chan []byte
+	httpClient          *http.Client
+	rest                *restClient
+	references          referenceCache
+	wsConn              *websocket.Conn
+	heartbeat           *time.Ticker
+	onStatsReport       atomic.Value
+	logger              *clientLogger
+	reorderBuffer       *reorderBuffer
+	qos                 *qosController
+	faultInjector       FaultInjector
+	config              atomic.Pointer[Config]
+	handlers            atomic.Value
+	handlersLock        sync.Mutex
+	filters             atomic.Value
+	statsInterval       int64
+	reloadLock          sync.Mutex
+	recorder            atomic.Value
+	recorderLock        sync.Mutex
+	overflow            *overflowQueue
+	overflowPolicy      atomic.Value
+	burstMode           *burstMode
+	work                func()
+	process             func([]byte)
+	stopping            chan bool
+	connectionState     uint32
+	connectionListeners []func(ConnectionStateChange)
+	listenersLock       sync.Mutex
+	readDone            chan bool
+	composeJoinMsg      func(string, SubscriptionOptions) []byte
+	composeLeaveMsg     func(string) []byte
 }
 
 func NewOptionsClient(
@@ -70,46 +166,71 @@ func NewOptionsClient(
 	onRefresh func(OptionRefresh),
 	onUnusualActivity func(OptionUnusualActivity)) *Client {
 	client := &Client{
-		isStopped:     true,
-		isClosed:      true,
-		workerCount:   1,
-		reconnected:   make(chan bool),
-		readChannel:   make(chan []byte, MAX_OPTIONS_QUEUE_DEPTH),
-		writeChannel:  make(chan []byte, 1000),
-		subscriptions: make(map[string]bool),
-		httpClient:    http.DefaultClient,
-		config:        c,
-	}
-	if onTrade != nil {
-		client.workerCount++
-	}
-	if onQuote != nil {
-		client.workerCount += 8
-	}
-	client.work = func() {
-		for {
-			if len(client.readChannel) == 0 {
-				if client.isClosed && client.isStopped {
-					defer client.closeWg.Done()
-					return
-				} else {
-					time.Sleep(time.Second)
-				}
-			}
-			workOnOptions(
-				client.readChannel,
-				onTrade,
-				onQuote,
-				onRefresh,
-				onUnusualActivity)
+		isStopped:           true,
+		isClosed:            true,
+		reconnected:         make(chan bool),
+		readChannel:         make(chan []byte, c.getReadQueueDepth(MAX_OPTIONS_QUEUE_DEPTH)),
+		writeChannel:        make(chan []byte, c.getWriteQueueDepth()),
+		subscriptions:       make(map[string]SubscriptionOptions),
+		directJoins:         make(map[string]bool),
+		failedSubscriptions: make(map[string]string),
+		groups:              make(map[string]map[string]bool),
+		chains:              make(map[string]ChainFilter),
+		httpClient:          getHTTPClient(c.HTTPClient),
+		rest:                newRestClient(getHTTPClient(c.HTTPClient), c.ApiKey, getRequestHeader(c.AppIdentifier, c.Headers), c.RestPolicy),
+		reorderBuffer:       c.getReorderBuffer(),
+		qos:                 newQoSController(),
+		references:          referenceCache{tickers: make(map[string]ReferenceData)},
+		logger:              newClientLogger(c.getLogger()),
+		statsInterval:       int64(c.getStatsReportInterval()),
+		overflow:            c.getOverflowQueue(),
+		stateStore:          c.getStateStore(),
+		symbols:             c.getSymbolTable(),
+	}
+	handlers := optionHandlers{
+		onTrade:           onTrade,
+		onQuote:           onQuote,
+		onRefresh:         onRefresh,
+		onUnusualActivity: onUnusualActivity,
+	}
+	client.config.Store(&c)
+	client.handlers.Store(handlers)
+	client.workerCount = c.getWorkerCount(handlers.getWorkerCount())
+	overflowPolicy, _ := c.getOverflowPolicy()
+	client.overflowPolicy.Store(overflowPolicy)
+	client.setKeepAlive(c)
+	client.setHeartbeat(c)
+	guard, _ := c.getSubscriptionGuard()
+	client.subscriptionGuard.Store(guard)
+	filters, filterErr := c.getOptionFilters()
+	if filterErr != nil {
+		client.logger.Error("Option Client - Invalid filter: %v\n", filterErr)
+	}
+	client.filters.Store(filters)
+	client.process = func(data []byte) {
+		handlers := client.handlers.Load().(optionHandlers).filtered(client.filters.Load().(optionFilters))
+		if client.reorderBuffer != nil {
+			handlers = handlers.reordered(client.reorderBuffer)
+		}
+		if routes := client.qos.getRoutes(); len(routes) > 0 {
+			handlers = handlers.conflated(client.qos, routes)
 		}
+		workOnOptions(
+			data,
+			client.releaseFrame,
+			client.logger,
+			handlers,
+			client.countEvent,
+			client.symbols)
 	}
-	client.composeJoinMsg = func(symbol string) []byte {
+	client.work = client.runWorker
+	client.composeJoinMsg = func(symbol string, options SubscriptionOptions) []byte {
+		handlers := client.handlers.Load().(optionHandlers)
 		return composeOptionJoinMsg(
-			onTrade != nil,
-			onQuote != nil,
-			onRefresh != nil,
-			onUnusualActivity != nil,
+			handlers.onTrade != nil && options.Trades,
+			handlers.onQuote != nil && options.Quotes,
+			handlers.onRefresh != nil && options.Refreshes,
+			handlers.onUnusualActivity != nil && options.UnusualActivity,
 			symbol)
 	}
 	client.composeLeaveMsg = composeOptionLeaveMsg
@@ -121,124 +242,231 @@ func NewEquitiesClient(
 	onTrade func(EquityTrade),
 	onQuote func(EquityQuote)) *Client {
 	client := &Client{
-		isStopped:     true,
-		isClosed:      true,
-		workerCount:   2,
-		reconnected:   make(chan bool),
-		readChannel:   make(chan []byte, MAX_EQUITIES_QUEUE_DEPTH),
-		writeChannel:  make(chan []byte, 1000),
-		subscriptions: make(map[string]bool),
-		httpClient:    http.DefaultClient,
-		config:        c,
-	}
-	if onQuote != nil {
-		client.workerCount += 2
-	}
-	client.work = func() {
-		for {
-			if len(client.readChannel) == 0 {
-				if client.isClosed && client.isStopped {
-					defer client.closeWg.Done()
-					return
-				} else {
-					time.Sleep(time.Second)
-				}
-			}
-			workOnEquities(
-				client.readChannel,
-				onTrade,
-				onQuote)
+		isStopped:           true,
+		isClosed:            true,
+		reconnected:         make(chan bool),
+		readChannel:         make(chan []byte, c.getReadQueueDepth(MAX_EQUITIES_QUEUE_DEPTH)),
+		writeChannel:        make(chan []byte, c.getWriteQueueDepth()),
+		subscriptions:       make(map[string]SubscriptionOptions),
+		directJoins:         make(map[string]bool),
+		failedSubscriptions: make(map[string]string),
+		groups:              make(map[string]map[string]bool),
+		chains:              make(map[string]ChainFilter),
+		httpClient:          getHTTPClient(c.HTTPClient),
+		rest:                newRestClient(getHTTPClient(c.HTTPClient), c.ApiKey, getRequestHeader(c.AppIdentifier, c.Headers), c.RestPolicy),
+		reorderBuffer:       c.getReorderBuffer(),
+		qos:                 newQoSController(),
+		references:          referenceCache{tickers: make(map[string]ReferenceData)},
+		logger:              newClientLogger(c.getLogger()),
+		statsInterval:       int64(c.getStatsReportInterval()),
+		overflow:            c.getOverflowQueue(),
+		stateStore:          c.getStateStore(),
+		symbols:             c.getSymbolTable(),
+	}
+	handlers := equityHandlers{
+		onTrade: onTrade,
+		onQuote: onQuote,
+	}
+	client.config.Store(&c)
+	client.handlers.Store(handlers)
+	client.workerCount = c.getWorkerCount(handlers.getWorkerCount())
+	overflowPolicy, _ := c.getOverflowPolicy()
+	client.overflowPolicy.Store(overflowPolicy)
+	client.setKeepAlive(c)
+	client.setHeartbeat(c)
+	guard, _ := c.getSubscriptionGuard()
+	client.subscriptionGuard.Store(guard)
+	filters, filterErr := c.getEquityFilters()
+	if filterErr != nil {
+		client.logger.Error("Equity Client - Invalid filter: %v\n", filterErr)
+	}
+	client.filters.Store(filters)
+	client.process = func(data []byte) {
+		handlers := client.handlers.Load().(equityHandlers).filtered(client.filters.Load().(equityFilters))
+		if client.reorderBuffer != nil {
+			handlers = handlers.reordered(client.reorderBuffer)
 		}
+		if routes := client.qos.getRoutes(); len(routes) > 0 {
+			handlers = handlers.conflated(client.qos, routes)
+		}
+		workOnEquities(
+			data,
+			client.releaseFrame,
+			client.logger,
+			handlers,
+			client.acceptSubProvider,
+			client.countEvent,
+			client.symbols)
 	}
-	client.composeJoinMsg = func(symbol string) []byte {
+	client.work = client.runWorker
+	client.composeJoinMsg = func(symbol string, options SubscriptionOptions) []byte {
+		handlers := client.handlers.Load().(equityHandlers)
 		return composeEquityJoinMsg(
-			onTrade != nil,
-			onQuote != nil,
+			handlers.onTrade != nil && options.Trades,
+			handlers.onQuote != nil && options.Quotes,
 			symbol)
 	}
 	client.composeLeaveMsg = composeEquityLeaveMsg
 	return client
 }
 
-func (client *Client) trySetToken() bool {
-	log.Print("Client - Authorizing...")
-	authUrl := client.config.getAuthUrl()
-	req, httpNewReqErr := http.NewRequest("GET", authUrl, nil)
+func (client *Client) trySetToken() error {
+	client.logger.Info("Client - Authorizing...")
+	if client.faultInjector != nil {
+		if faultErr := client.faultInjector.InjectAuthFailure(); faultErr != nil {
+			client.logger.Error("Client - Authorization Failure: %v\n", faultErr)
+			client.reportAuthFailure(0, faultErr)
+			return faultErr
+		}
+	}
+	config := client.getConfig()
+	authUrl, authUrlErr := config.getAuthUrl()
+	if authUrlErr != nil {
+		client.logger.Error("Client - Authorization Failure: %v\n", authUrlErr)
+		return authUrlErr
+	}
+	ctx := context.Background()
+	if dialTimeout := config.getDialTimeout(); dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialTimeout)
+		defer cancel()
+	}
+	req, httpNewReqErr := http.NewRequestWithContext(ctx, "GET", authUrl, nil)
 	if httpNewReqErr != nil {
-		log.Printf("Client - Authorization Failure: %v\n", httpNewReqErr)
-		return false
+		client.logger.Error("Client - Authorization Failure: %v\n", httpNewReqErr)
+		return httpNewReqErr
 	}
-	req.Header.Add("Client-Information", "IntrinioRealtimeOptionsGoSDKv2.0")
+	req.Header = getRequestHeader(config.AppIdentifier, config.Headers)
 	resp, httpDoErr := client.httpClient.Do(req)
 	if httpDoErr != nil {
-		log.Printf("Client - Authorization Failure: %v\n", httpDoErr)
-		return false
+		client.logger.Error("Client - Authorization Failure: %v\n", httpDoErr)
+		client.reportAuthFailure(0, httpDoErr)
+		return httpDoErr
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		log.Printf("Client - Authorization Failure: %v\n", resp.Status)
-		return false
+		client.logger.Error("Client - Authorization Failure: %v\n", resp.Status)
+		statusErr := fmt.Errorf("Client - Authorization Failure: %s", resp.Status)
+		client.reportAuthFailure(resp.StatusCode, statusErr)
+		return statusErr
 	}
-	defer resp.Body.Close()
 	body, readErr := io.ReadAll(resp.Body)
 	if readErr != nil {
-		log.Printf("Client - Authorization Failure: %v\n", readErr)
-		return false
+		client.logger.Error("Client - Authorization Failure: %v\n", readErr)
+		client.reportAuthFailure(0, readErr)
+		return readErr
 	}
 	client.token = string(body)
 	client.tokenUpdateTime = time.Now()
-	log.Print("Client - Authorization successful")
-	return true
+	client.markStateDirty()
+	client.logger.Info("Client - Authorization successful")
+	return nil
 }
 
-func (client *Client) getToken() string {
-	if time.Since(client.tokenUpdateTime) < (24 * time.Hour) {
-		return client.token
+func (client *Client) initWebSocket(token string) error {
+	client.logger.Info("Client - Connecting...")
+	wsUrl, wsUrlErr := client.getConfig().getWSUrl(token)
+	if wsUrlErr != nil {
+		return wsUrlErr
 	}
-	doBackoff(client.trySetToken, &client.isStopped)
-	return client.token
-}
-
-func (client *Client) initWebSocket(token string) {
-	log.Println("Client - Connecting...")
-	wsUrl := client.config.getWSUrl(token)
-	wsHeader := map[string][]string{"UseNewEquitiesFormat": {"v2"}, "Client-Information": {"IntrinioRealtimeOptionsGoSDKv2.0"}}
-	dialer := websocket.Dialer{
-		ReadBufferSize:  10240,
-		WriteBufferSize: 128,
-	}
-	conn, resp, dialErr := dialer.Dial(wsUrl, wsHeader)
+	conn, resp, dialErr := client.dial(client.getDialer(), wsUrl, client.getWSHeader())
 	if dialErr != nil {
-		log.Printf("Client - Connection failure: %v\n", dialErr)
-		return
+		client.logger.Error("Client - Connection failure: %v\n", dialErr)
+		return dialErr
 	}
-	log.Printf("Client - Status: %s\n", resp.Status)
+	client.logger.Info("Client - Status: %s\n", resp.Status)
+	client.configureWebSocket(conn)
 	client.wsConn = conn
 	if reflect.ValueOf(client.heartbeat).IsZero() {
 		//log.Println("Client - Starting heartbeat")
-		client.heartbeat = time.NewTicker(20 * time.Second)
+		client.heartbeat = time.NewTicker(client.getHeartbeatInterval())
 	}
 	client.isClosed = false
+	return nil
 }
 
-func (client *Client) tryResetWebSocket() bool {
-	wsUrl := client.config.getWSUrl(client.token)
-	wsHeader := map[string][]string{"UseNewEquitiesFormat": {"true"}}
+func (client *Client) getConfig() Config {
+	return *client.config.Load()
+}
+
+func (client *Client) getWSHeader() http.Header {
+	config := client.getConfig()
+	header := getRequestHeader(config.AppIdentifier, config.Headers)
+	header.Del("UseNewEquitiesFormat")
+	header["UseNewEquitiesFormat"] = []string{"v2"}
+	return header
+}
+
+func (client *Client) getDialer() websocket.Dialer {
+	config := client.getConfig()
+	if config.Dialer != nil {
+		dialer := *config.Dialer
+		if dialer.ReadBufferSize == 0 {
+			dialer.ReadBufferSize = config.getReadBufferSize()
+		}
+		if dialer.WriteBufferSize == 0 {
+			dialer.WriteBufferSize = config.getWriteBufferSize()
+		}
+		if dialTimeout := config.getDialTimeout(); dialTimeout > 0 {
+			dialer.HandshakeTimeout = dialTimeout
+		}
+		return dialer
+	}
 	dialer := websocket.Dialer{
-		ReadBufferSize:  10240,
-		WriteBufferSize: 128,
+		ReadBufferSize:  config.getReadBufferSize(),
+		WriteBufferSize: config.getWriteBufferSize(),
+	}
+	if transport, ok := client.httpClient.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+	if dialTimeout := config.getDialTimeout(); dialTimeout > 0 {
+		dialer.HandshakeTimeout = dialTimeout
+	} else if client.httpClient.Timeout > 0 {
+		dialer.HandshakeTimeout = client.httpClient.Timeout
+	}
+	return dialer
+}
+
+func (client *Client) dial(dialer websocket.Dialer, wsUrl string, wsHeader http.Header) (*websocket.Conn, *http.Response, error) {
+	if client.faultInjector != nil {
+		if faultErr := client.faultInjector.InjectDialFailure(); faultErr != nil {
+			return nil, nil, faultErr
+		}
+	}
+	return dialer.Dial(wsUrl, wsHeader)
+}
+
+func (client *Client) configureWebSocket(conn *websocket.Conn) {
+	conn.SetReadLimit(MAX_FRAME_SIZE)
+	client.onKeepAliveResponse()
+	conn.SetPongHandler(func(string) error {
+		client.onKeepAliveResponse()
+		return conn.SetReadDeadline(time.Now().Add(client.getStaleTimeout()))
+	})
+}
+
+func (client *Client) tryResetWebSocket() bool {
+	wsUrl, wsUrlErr := client.getConfig().getWSUrl(client.token)
+	if wsUrlErr != nil {
+		client.logger.Error("Client - Connection failure: %v\n", wsUrlErr)
+		return false
 	}
-	conn, resp, dialErr := dialer.Dial(wsUrl, wsHeader)
+	conn, resp, dialErr := client.dial(client.getDialer(), wsUrl, client.getWSHeader())
 	if dialErr != nil {
+		if (resp != nil) && (resp.StatusCode == http.StatusUnauthorized) {
+			client.logger.Warn("Client - Token rejected, authorizing again")
+			client.tokenUpdateTime = time.Time{}
+		}
 		return false
 	}
-	log.Printf("Client - Status: %s\n", resp.Status)
+	client.logger.Info("Client - Status: %s\n", resp.Status)
+	client.configureWebSocket(conn)
 	client.wsConn = conn
-	log.Printf("Client - Rejoining")
-	for key := range client.subscriptions {
-		client.writeChannel <- client.composeJoinMsg(key)
-	}
 	client.reconnected <- true
 	client.isClosed = false
+	client.logger.Info("Client - Rejoining")
+	client.rejoinAll()
 	return true
 }
 
@@ -246,11 +474,11 @@ func (client *Client) reconnect() {
 	client.wsConn.Close()
 	time.Sleep(10 * time.Second)
 	doBackoff(func() bool {
-		log.Println("Client - Reconnecting...")
-		if time.Since(client.tokenUpdateTime) < (24 * time.Hour) {
+		client.logger.Info("Client - Reconnecting...")
+		if time.Since(client.tokenUpdateTime) < TOKEN_LIFETIME {
 			return client.tryResetWebSocket()
 		} else {
-			if client.trySetToken() {
+			if client.trySetToken() == nil {
 				return client.tryResetWebSocket()
 			} else {
 				return false
@@ -259,42 +487,78 @@ func (client *Client) reconnect() {
 	}, &client.isStopped)
 }
 
-func (client *Client) write() {
+func (client *Client) write(stopping chan bool) {
 	for {
-		if client.isStopped {
+		var writeChannel chan []byte = client.writeChannel
+		var reconnecting <-chan time.Time = nil
+		if client.isClosed {
+			writeChannel = nil
+			reconnecting = time.After(time.Second)
+		}
+		select {
+		case <-stopping:
 			remainingWriteCount := len(client.writeChannel)
 			for i := 0; i < remainingWriteCount; i++ {
 				data := <-client.writeChannel
 				client.wsConn.WriteMessage(websocket.BinaryMessage, data)
 			}
 			time.Sleep(500 * time.Millisecond)
-			log.Println("Client - Sending close message")
+			client.logger.Info("Client - Sending close message")
 			client.wsConn.WriteControl(
 				websocket.CloseMessage,
 				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 				time.Now().Add(time.Second*2))
 			return
+		case <-reconnecting:
+		case <-client.heartbeat.C:
+			if !client.isClosed {
+				client.writeKeepAlive()
+				client.saveState(false)
+			}
+		case data := <-writeChannel:
+			client.wsConn.WriteMessage(websocket.BinaryMessage, data)
 		}
-		if client.isClosed {
-			time.Sleep(time.Second)
-		} else {
-			select {
-			case <-client.heartbeat.C:
-				client.wsConn.WriteMessage(websocket.BinaryMessage, []byte{})
-				client.LogStats()
-				if len(client.writeChannel) < 2 {
-					time.Sleep(time.Duration(500) * time.Millisecond)
-				}
-			default:
-				select {
-				case data := <-client.writeChannel:
-					client.wsConn.WriteMessage(websocket.BinaryMessage, data)
-				default:
-				}
-				if len(client.writeChannel) < 2 {
-					time.Sleep(time.Duration(500) * time.Millisecond)
-				}
+	}
+}
+
+func (client *Client) getFrameBuffer() []byte {
+	if buffer, ok := client.framePool.Get().(*[]byte); ok {
+		return (*buffer)[:0]
+	}
+	return make([]byte, 0, FRAME_BUFFER_SIZE)
+}
+
+func (client *Client) releaseFrame(data []byte) {
+	client.framePool.Put(&data)
+}
+
+func (client *Client) readFrame() (int, []byte, error) {
+	if client.faultInjector != nil {
+		if faultErr := client.faultInjector.InjectReadFailure(); faultErr != nil {
+			return 0, nil, faultErr
+		}
+	}
+	client.wsConn.SetReadDeadline(time.Now().Add(client.getStaleTimeout()))
+	msgType, reader, err := client.wsConn.NextReader()
+	if err != nil {
+		return msgType, nil, err
+	}
+	data := client.getFrameBuffer()
+	for {
+		if len(data) == cap(data) {
+			data = append(data, 0)[:len(data)]
+		}
+		n, readErr := reader.Read(data[len(data):cap(data)])
+		data = data[:len(data)+n]
+		if readErr == io.EOF {
+			if client.faultInjector != nil {
+				data = client.faultInjector.InjectFrameFault(data)
 			}
+			return msgType, data, nil
+		}
+		if readErr != nil {
+			client.releaseFrame(data)
+			return msgType, nil, readErr
 		}
 	}
 }
@@ -303,59 +567,150 @@ func (client *Client) read() {
 	var highWatermark int = cap(client.readChannel) * 9 / 10
 	var queueFull bool = false
 	for {
-		msgType, data, err := client.wsConn.ReadMessage()
+		msgType, data, err := client.readFrame()
 		if err != nil {
 			client.isClosed = true
-			log.Printf("Client - Received message '%v'\n", err)
+			if isStale(err) {
+				atomic.AddUint64(&client.staleCount, 1)
+				client.logger.Warn("Client - No data or pong received for %v, connection is stale\n", client.getStaleTimeout())
+			}
+			client.logger.Warn("Client - Received message '%v'\n", err)
 			if client.isStopped {
+				close(client.readDone)
 				return
 			}
+			client.setState(CONNECTION_RECONNECTING, err)
 			go client.reconnect()
 			<-client.reconnected
-			log.Println("Client - Reconnected")
+			atomic.AddUint64(&client.reconnectCount, 1)
+			client.logger.Info("Client - Reconnected")
+			client.setState(CONNECTION_CONNECTED, nil)
 		} else if msgType == websocket.BinaryMessage {
-			client.dataMsgCount++
-			select {
-			case client.readChannel <- data:
+			atomic.AddUint64(&client.dataMsgCount, 1)
+			client.countFrame(data)
+			client.record(data)
+			if client.enqueueFrame(data) {
+				client.updateQueueHighWatermark()
 				if queueFull && len(client.readChannel) < highWatermark {
 					queueFull = false
-					log.Println("Client - read channel draining")
-				}
-			default:
-				if !queueFull {
-					log.Println("Client - read channel full")
-					queueFull = true
+					client.logger.Info("Client - read channel draining")
 				}
+			} else if !queueFull {
+				client.logger.Warn("Client - read channel full")
+				queueFull = true
 			}
 		} else if msgType == websocket.TextMessage {
-			client.txtMsgCount++
-			log.Printf("Client - %s\n", string(data))
+			atomic.AddUint32(&client.txtMsgCount, 1)
+			client.countFrame(data)
+			client.onTextMessage(string(data))
+			client.releaseFrame(data)
 		}
 	}
 }
 
-func (client *Client) Start() {
+func (client *Client) Start() error {
+	config := client.getConfig()
+	if validateErr := config.validate(client.isOptionsClient()); validateErr != nil {
+		client.logger.Error("Client - Invalid config: %v\n", validateErr)
+		return validateErr
+	}
+	atomic.StoreUint32(&client.stateFrozen, 0)
+	client.setState(CONNECTION_CONNECTING, nil)
+	if connectErr := client.connect(); connectErr != nil {
+		client.setState(CONNECTION_STOPPED, connectErr)
+		return connectErr
+	}
 	client.isStopped = false
-	token := client.getToken()
-	client.initWebSocket(token)
+	client.stopping = make(chan bool)
+	client.readDone = make(chan bool)
+	go client.refreshToken(client.stopping)
 	for w := 0; w < client.workerCount; w++ {
 		client.closeWg.Add(1)
 		go client.work()
 	}
+	if client.reorderBuffer != nil {
+		client.reorderBuffer.start()
+	}
+	client.qos.start()
+	if client.overflow != nil {
+		go client.drainOverflow()
+	}
+	if client.burstMode != nil {
+		go client.runBurstMode()
+	}
 	go client.read()
-	go client.write()
+	go client.write(client.stopping)
+	go client.report(client.stopping)
+	if client.isOptionsClient() {
+		go client.refreshOptionChains()
+	}
+	client.restoreSubscriptions()
+	if len(config.Symbols) > 0 {
+		client.SwapGroup(CONFIG_GROUP, config.Symbols)
+	}
+	client.setState(CONNECTION_CONNECTED, nil)
+	return nil
+}
+
+func (client *Client) connect() error {
+	usingSavedToken := client.loadState()
+	if time.Since(client.tokenUpdateTime) >= TOKEN_LIFETIME {
+		if authErr := client.trySetToken(); authErr != nil {
+			return authErr
+		}
+	}
+	if wsErr := client.initWebSocket(client.token); wsErr != nil {
+		if !usingSavedToken {
+			return wsErr
+		}
+		client.logger.Info("Client - Saved token rejected, authorizing again")
+		if authErr := client.trySetToken(); authErr != nil {
+			return authErr
+		}
+		return client.initWebSocket(client.token)
+	}
+	return nil
+}
+
+func (client *Client) join(symbol string, options SubscriptionOptions) bool {
+	previous, ok := client.subscriptions[symbol]
+	if ok && (previous == options) {
+		return false
+	}
+	if !ok && !client.allowJoin(symbol) {
+		delete(client.directJoins, symbol)
+		return false
+	}
+	client.subscriptions[symbol] = options
+	client.markStateDirty()
+	client.writeChannel <- client.composeJoinMsg(symbol, options)
+	client.logger.Debug("Client - Composed join msg for channel %s\n", symbol)
+	return true
+}
+
+func (client *Client) leave(symbol string) bool {
+	if _, ok := client.subscriptions[symbol]; !ok {
+		return false
+	}
+	client.writeChannel <- client.composeLeaveMsg(symbol)
+	delete(client.subscriptions, symbol)
+	delete(client.failedSubscriptions, symbol)
+	client.markStateDirty()
+	client.resetGuard()
+	client.logger.Debug("Client - Composed leave msg for channel %s\n", symbol)
+	return true
 }
 
 func (client *Client) Join(symbol string) {
-	s := strings.TrimSpace(symbol)
-	if s != "" {
+	symbol = strings.TrimSpace(symbol)
+	if symbol != "" {
 		for client.isClosed {
 			time.Sleep(time.Second)
 		}
-		if !client.subscriptions[symbol] {
-			client.subscriptions[symbol] = true
-			client.writeChannel <- client.composeJoinMsg(symbol)
-		}
+		client.subscriptionsLock.Lock()
+		defer client.subscriptionsLock.Unlock()
+		client.directJoins[symbol] = true
+		client.join(symbol, DefaultSubscriptionOptions)
 	}
 }
 
@@ -363,41 +718,152 @@ func (client *Client) JoinMany(symbols []string) {
 	for client.isClosed {
 		time.Sleep(time.Second)
 	}
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
 	for i := 0; i < len(symbols); i++ {
-		s := strings.TrimSpace(symbols[i])
-		if s != "" && !client.subscriptions[symbols[i]] {
-			client.subscriptions[symbols[i]] = true
-			client.writeChannel <- client.composeJoinMsg(symbols[i])
+		if s := strings.TrimSpace(symbols[i]); s != "" {
+			client.directJoins[s] = true
+			client.join(s, DefaultSubscriptionOptions)
 		}
 	}
 }
 
-func (client *Client) JoinLobby() {
+func (client *Client) JoinWithOptions(symbol string, options SubscriptionOptions) bool {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return false
+	}
+	if !options.Trades && !options.Quotes && !options.Refreshes && !options.UnusualActivity {
+		client.logger.Warn("Client - No message types selected for channel %s\n", symbol)
+		return false
+	}
+	for client.isClosed {
+		time.Sleep(time.Second)
+	}
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	client.directJoins[symbol] = true
+	return client.join(symbol, options)
+}
+
+func (client *Client) getRejoinMsgs() [][]byte {
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	msgs := make([][]byte, 0, len(client.subscriptions))
+	for _, key := range sortedKeys(client.subscriptions) {
+		msgs = append(msgs, client.composeJoinMsg(key, client.subscriptions[key]))
+	}
+	client.failedSubscriptions = make(map[string]string)
+	return msgs
+}
+
+func (client *Client) rejoinAll() {
+	for _, msg := range client.getRejoinMsgs() {
+		client.writeChannel <- msg
+	}
+}
+
+func (client *Client) JoinLobby(tradesOnly bool) {
+	for client.isClosed {
+		time.Sleep(time.Second)
+	}
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	client.directJoins[LOBBY_CHANNEL] = true
+	if _, ok := client.subscriptions[LOBBY_CHANNEL]; !client.join(LOBBY_CHANNEL, getSubscriptionOptions(tradesOnly)) && ok {
+		client.logger.Warn("Client - lobby channel already joined")
+	}
+}
+
+func (client *Client) SwapGroup(name string, symbols []string) ([]string, []string) {
 	for client.isClosed {
 		time.Sleep(time.Second)
 	}
-	if !client.subscriptions["$FIREHOSE"] {
-		client.subscriptions["$FIREHOSE"] = true
-		client.writeChannel <- client.composeJoinMsg("$FIREHOSE")
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	members := make(map[string]bool)
+	for i := 0; i < len(symbols); i++ {
+		if s := strings.TrimSpace(symbols[i]); s != "" {
+			members[s] = true
+		}
+	}
+	previous := client.groups[name]
+	if len(members) > 0 {
+		client.groups[name] = members
 	} else {
-		log.Print("Client - lobby channel already joined")
+		delete(client.groups, name)
+	}
+	joined := make([]string, 0)
+	left := make([]string, 0)
+	for _, symbol := range sortedKeys(members) {
+		if _, ok := client.subscriptions[symbol]; !ok && client.join(symbol, DefaultSubscriptionOptions) {
+			joined = append(joined, symbol)
+		}
 	}
+	for _, symbol := range sortedKeys(previous) {
+		if !members[symbol] && !client.isHeld(symbol) && client.leave(symbol) {
+			left = append(left, symbol)
+		}
+	}
+	client.qos.route(client.groups)
+	client.logger.Info("Client - Swapped group %s (joined: %d, left: %d)\n", name, len(joined), len(left))
+	return joined, left
+}
+
+func (client *Client) LeaveGroup(name string) {
+	client.SwapGroup(name, nil)
+}
+
+func (client *Client) GetGroup(name string) []string {
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	return sortedKeys(client.groups[name])
+}
+
+func (client *Client) GetSubscriptions() map[string]SubscriptionOptions {
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	subscriptions := make(map[string]SubscriptionOptions, len(client.subscriptions))
+	for symbol, options := range client.subscriptions {
+		subscriptions[symbol] = options
+	}
+	return subscriptions
+}
+
+func (client *Client) isHeld(symbol string) bool {
+	if client.directJoins[symbol] {
+		return true
+	}
+	for _, members := range client.groups {
+		if members[symbol] {
+			return true
+		}
+	}
+	return false
 }
 
 func (client *Client) LeaveAll() {
-	for key := range client.subscriptions {
-		client.writeChannel <- client.composeLeaveMsg(key)
-		delete(client.subscriptions, key)
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	for _, key := range sortedKeys(client.subscriptions) {
+		client.leave(key)
 	}
+	client.directJoins = make(map[string]bool)
+	client.groups = make(map[string]map[string]bool)
+	client.qos.route(client.groups)
 }
 
 func (client *Client) Leave(symbol string) {
-	s := strings.TrimSpace(symbol)
-	if s != "" {
-		if client.subscriptions[symbol] {
-			client.writeChannel <- client.composeLeaveMsg(symbol)
-			delete(client.subscriptions, symbol)
+	symbol = strings.TrimSpace(symbol)
+	if symbol != "" {
+		client.subscriptionsLock.Lock()
+		defer client.subscriptionsLock.Unlock()
+		delete(client.directJoins, symbol)
+		for _, members := range client.groups {
+			delete(members, symbol)
 		}
+		client.qos.route(client.groups)
+		client.leave(symbol)
 	}
 }
 
@@ -407,22 +873,191 @@ func (client *Client) LeaveMany(symbols []string) {
 	}
 }
 
-func (client *Client) LeaveLobby(composeLeave func(string)) {
-	if client.subscriptions["$FIREHOSE"] {
-		client.writeChannel <- client.composeLeaveMsg("$FIREHOSE")
-		delete(client.subscriptions, "$FIREHOSE")
-	}
+func (client *Client) LeaveLobby() {
+	client.Leave(LOBBY_CHANNEL)
 }
 
 func (client *Client) Stop() {
-	log.Println("Client - Stopping...")
+	client.logger.Info("Client - Stopping...")
+	client.saveState(true)
+	atomic.StoreUint32(&client.stateFrozen, 1)
+	atomic.StoreUint32(&client.stateDirty, 0)
 	client.LeaveAll()
 	client.isStopped = true
+	if client.stopping != nil {
+		close(client.stopping)
+		client.stopping = nil
+	}
 	client.closeWg.Wait()
+	client.qos.stop()
+	if client.reorderBuffer != nil {
+		client.reorderBuffer.stop()
+	}
+	if recordErr := client.StopRecording(); recordErr != nil {
+		client.logger.Warn("Client - Failure to close recording: %v\n", recordErr)
+	}
 	//client.LogStats()
-	log.Println("Client - Stopped")
+	client.setState(CONNECTION_STOPPED, nil)
+	client.logger.Info("Client - Stopped")
+}
+
+func (client *Client) runWorker() {
+	defer client.closeWg.Done()
+	for {
+		select {
+		case data := <-client.readChannel:
+			client.process(data)
+		case <-client.readDone:
+			for {
+				select {
+				case data := <-client.readChannel:
+					client.process(data)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (client *Client) updateHandlers(handlers any, workerCount int, maskChanged bool) bool {
+	workerCount = client.getConfig().getWorkerCount(workerCount)
+	client.handlers.Store(handlers)
+	if client.isStopped {
+		client.workerCount = workerCount
+	} else {
+		for client.workerCount < workerCount {
+			client.workerCount++
+			client.closeWg.Add(1)
+			go client.work()
+		}
+	}
+	return maskChanged && !client.isClosed
+}
+
+func (client *Client) rejoinChangedHandlers(rejoin bool) {
+	if rejoin {
+		client.logger.Info("Client - Handlers changed, rejoining")
+		client.rejoinAll()
+	}
+}
+
+func (client *Client) SetOnEquityTrade(onTrade func(EquityTrade)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(equityHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnEquityTrade requires an equities client")
+		return
+	}
+	maskChanged := (handlers.onTrade == nil) != (onTrade == nil)
+	handlers.onTrade = onTrade
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
+}
+
+func (client *Client) SetOnEquityQuote(onQuote func(EquityQuote)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(equityHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnEquityQuote requires an equities client")
+		return
+	}
+	maskChanged := (handlers.onQuote == nil) != (onQuote == nil)
+	handlers.onQuote = onQuote
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
 }
 
-func (client *Client) LogStats() {
-	log.Printf("Client - Data Message Count: %d, Queue Depth: %d", client.dataMsgCount, len(client.readChannel))
+func (client *Client) SetOnOptionTrade(onTrade func(OptionTrade)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(optionHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnOptionTrade requires an options client")
+		return
+	}
+	maskChanged := (handlers.onTrade == nil) != (onTrade == nil)
+	handlers.onTrade = onTrade
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
+}
+
+func (client *Client) SetOnOptionQuote(onQuote func(OptionQuote)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(optionHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnOptionQuote requires an options client")
+		return
+	}
+	maskChanged := (handlers.onQuote == nil) != (onQuote == nil)
+	handlers.onQuote = onQuote
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
+}
+
+func (client *Client) SetOnOptionRefresh(onRefresh func(OptionRefresh)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(optionHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnOptionRefresh requires an options client")
+		return
+	}
+	maskChanged := (handlers.onRefresh == nil) != (onRefresh == nil)
+	handlers.onRefresh = onRefresh
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
+}
+
+func (client *Client) SetOnOptionUnusualActivity(onUnusualActivity func(OptionUnusualActivity)) {
+	client.handlersLock.Lock()
+	handlers, ok := client.handlers.Load().(optionHandlers)
+	if !ok {
+		client.handlersLock.Unlock()
+		client.logger.Warn("Client - SetOnOptionUnusualActivity requires an options client")
+		return
+	}
+	maskChanged := (handlers.onUnusualActivity == nil) != (onUnusualActivity == nil)
+	handlers.onUnusualActivity = onUnusualActivity
+	rejoin := client.updateHandlers(handlers, handlers.getWorkerCount(), maskChanged)
+	client.handlersLock.Unlock()
+	client.rejoinChangedHandlers(rejoin)
+}
+
+func (client *Client) acceptSubProvider(subProvider SubProvider) bool {
+	if int(subProvider) >= SUB_PROVIDER_COUNT {
+		return true
+	}
+	atomic.AddUint64(&client.subProviderCounts[subProvider], 1)
+	return atomic.LoadUint32(&client.disabledSubProvider[subProvider]) == 0
+}
+
+func (client *Client) SetSubProviderEnabled(subProvider SubProvider, enabled bool) {
+	if int(subProvider) >= SUB_PROVIDER_COUNT {
+		client.logger.Warn("Client - Invalid sub-provider: %d\n", subProvider)
+		return
+	}
+	var disabled uint32 = 0
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreUint32(&client.disabledSubProvider[subProvider], disabled)
+}
+
+func (client *Client) GetSubProviderCounts() map[SubProvider]uint64 {
+	counts := make(map[SubProvider]uint64)
+	for i := 0; i < SUB_PROVIDER_COUNT; i++ {
+		if count := atomic.LoadUint64(&client.subProviderCounts[i]); count > 0 {
+			counts[SubProvider(i)] = count
+		}
+	}
+	return counts
 }
@@ -0,0 +1,147 @@
+package intrinio
+
+import (
+	"strings"
+)
+
+type TradeConditions uint32
+
+const (
+	CONDITION_ODD_LOT TradeConditions = 1 << iota
+	CONDITION_EXTENDED_HOURS
+	CONDITION_DERIVATIVELY_PRICED
+	CONDITION_AVERAGE_PRICE
+	CONDITION_OUT_OF_SEQUENCE
+	CONDITION_INTERMARKET_SWEEP
+	CONDITION_OPENING
+	CONDITION_CLOSING
+	CONDITION_OFFICIAL_PRICE
+	CONDITION_NON_REGULAR_SETTLEMENT
+	CONDITION_CONTINGENT
+	CONDITION_PRICE_VARIATION
+	CONDITION_PRIOR_REFERENCE
+	CONDITION_CORRECTION
+)
+
+var tradeConditionNames = []struct {
+	flag TradeConditions
+	name string
+}{
+	{CONDITION_ODD_LOT, "ODD_LOT"},
+	{CONDITION_EXTENDED_HOURS, "EXTENDED_HOURS"},
+	{CONDITION_DERIVATIVELY_PRICED, "DERIVATIVELY_PRICED"},
+	{CONDITION_AVERAGE_PRICE, "AVERAGE_PRICE"},
+	{CONDITION_OUT_OF_SEQUENCE, "OUT_OF_SEQUENCE"},
+	{CONDITION_INTERMARKET_SWEEP, "INTERMARKET_SWEEP"},
+	{CONDITION_OPENING, "OPENING"},
+	{CONDITION_CLOSING, "CLOSING"},
+	{CONDITION_OFFICIAL_PRICE, "OFFICIAL_PRICE"},
+	{CONDITION_NON_REGULAR_SETTLEMENT, "NON_REGULAR_SETTLEMENT"},
+	{CONDITION_CONTINGENT, "CONTINGENT"},
+	{CONDITION_PRICE_VARIATION, "PRICE_VARIATION"},
+	{CONDITION_PRIOR_REFERENCE, "PRIOR_REFERENCE"},
+	{CONDITION_CORRECTION, "CORRECTION"},
+}
+
+const CONDITIONS_NOT_ELIGIBLE_FOR_LAST TradeConditions = CONDITION_ODD_LOT |
+	CONDITION_EXTENDED_HOURS |
+	CONDITION_DERIVATIVELY_PRICED |
+	CONDITION_AVERAGE_PRICE |
+	CONDITION_OUT_OF_SEQUENCE |
+	CONDITION_OFFICIAL_PRICE |
+	CONDITION_NON_REGULAR_SETTLEMENT |
+	CONDITION_CONTINGENT |
+	CONDITION_PRICE_VARIATION |
+	CONDITION_PRIOR_REFERENCE |
+	CONDITION_CORRECTION
+
+var commonConditionCodes map[byte]TradeConditions = map[byte]TradeConditions{
+	'C': CONDITION_NON_REGULAR_SETTLEMENT,
+	'F': CONDITION_INTERMARKET_SWEEP,
+	'H': CONDITION_PRICE_VARIATION,
+	'I': CONDITION_ODD_LOT,
+	'M': CONDITION_OFFICIAL_PRICE | CONDITION_CLOSING,
+	'N': CONDITION_NON_REGULAR_SETTLEMENT,
+	'O': CONDITION_OPENING,
+	'P': CONDITION_PRIOR_REFERENCE,
+	'Q': CONDITION_OFFICIAL_PRICE | CONDITION_OPENING,
+	'R': CONDITION_NON_REGULAR_SETTLEMENT,
+	'T': CONDITION_EXTENDED_HOURS,
+	'U': CONDITION_EXTENDED_HOURS | CONDITION_OUT_OF_SEQUENCE,
+	'V': CONDITION_CONTINGENT,
+	'Z': CONDITION_OUT_OF_SEQUENCE,
+	'4': CONDITION_DERIVATIVELY_PRICED,
+	'5': CONDITION_OPENING,
+	'6': CONDITION_CLOSING,
+	'7': CONDITION_CONTINGENT,
+	'9': CONDITION_OFFICIAL_PRICE | CONDITION_CLOSING | CONDITION_CORRECTION,
+}
+
+var ctaConditionCodes map[byte]TradeConditions = map[byte]TradeConditions{
+	'B': CONDITION_AVERAGE_PRICE,
+}
+
+var utpConditionCodes map[byte]TradeConditions = map[byte]TradeConditions{
+	'W': CONDITION_AVERAGE_PRICE,
+}
+
+func getConditionCodes(subProvider SubProvider) map[byte]TradeConditions {
+	switch subProvider {
+	case SUB_PROVIDER_CTA_A, SUB_PROVIDER_CTA_B:
+		return ctaConditionCodes
+	case SUB_PROVIDER_UTP, SUB_PROVIDER_OTC, SUB_PROVIDER_NASDAQ_BASIC:
+		return utpConditionCodes
+	}
+	return nil
+}
+
+func DecodeTradeConditions(subProvider SubProvider, conditions string) TradeConditions {
+	var result TradeConditions = 0
+	providerCodes := getConditionCodes(subProvider)
+	for i := 0; i < len(conditions); i++ {
+		code := conditions[i]
+		if flags, ok := providerCodes[code]; ok {
+			result |= flags
+		} else {
+			result |= commonConditionCodes[code]
+		}
+	}
+	return result
+}
+
+func (conditions TradeConditions) Has(flag TradeConditions) bool {
+	return (conditions & flag) == flag
+}
+
+func (conditions TradeConditions) IsEligibleForLast() bool {
+	return (conditions & CONDITIONS_NOT_ELIGIBLE_FOR_LAST) == 0
+}
+
+func (conditions TradeConditions) String() string {
+	names := make([]string, 0)
+	for _, entry := range tradeConditionNames {
+		if conditions.Has(entry.flag) {
+			names = append(names, entry.name)
+		}
+	}
+	if len(names) == 0 {
+		return "REGULAR"
+	}
+	return strings.Join(names, "|")
+}
+
+func (trade EquityTrade) GetConditions() TradeConditions {
+	return DecodeTradeConditions(trade.GetSubProvider(), trade.Conditions)
+}
+
+func (trade EquityTrade) IsEligibleForLast() bool {
+	return trade.GetConditions().IsEligibleForLast()
+}
+
+func (trade EquityTrade) IsExtendedHours() bool {
+	return trade.GetConditions().Has(CONDITION_EXTENDED_HOURS)
+}
+
+func (trade EquityTrade) IsOddLot() bool {
+	return trade.GetConditions().Has(CONDITION_ODD_LOT)
+}
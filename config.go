@@ -2,9 +2,14 @@ package intrinio
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 type Provider string
@@ -14,78 +19,398 @@ const (
 	IEX          Provider = "IEX"
 	DELAYED_SIP  Provider = "DELAYED_SIP"
 	NASDAQ_BASIC Provider = "NASDAQ_BASIC"
+	CBOE_ONE     Provider = "CBOE_ONE"
 	MANUAL       Provider = "MANUAL"
 )
 
+type OverflowPolicy string
+
+const (
+	OVERFLOW_DROP_NEWEST OverflowPolicy = "DROP_NEWEST"
+	OVERFLOW_DROP_OLDEST OverflowPolicy = "DROP_OLDEST"
+	OVERFLOW_BLOCK       OverflowPolicy = "BLOCK"
+)
+
+type KeepAliveMode string
+
+const (
+	KEEPALIVE_BOTH         KeepAliveMode = "BOTH"
+	KEEPALIVE_PING         KeepAliveMode = "PING"
+	KEEPALIVE_EMPTY_BINARY KeepAliveMode = "EMPTY_BINARY"
+	KEEPALIVE_MESSAGE      KeepAliveMode = "MESSAGE"
+)
+
+type SubscriptionGuardMode string
+
+const (
+	SUBSCRIPTION_GUARD_WARN  SubscriptionGuardMode = "WARN"
+	SUBSCRIPTION_GUARD_BLOCK SubscriptionGuardMode = "BLOCK"
+)
+
+var (
+	ErrMissingApiKey       = errors.New("Client - A valid API key must be provided (either via the config file or the INTRINIO_API_KEY env variable)")
+	ErrInvalidProvider     = errors.New("Client - Config must specify a valid provider")
+	ErrMissingIPAddress    = errors.New("Client - Config must specify an IP address for manual configuration")
+	ErrInvalidLogLevel     = errors.New("Client - Config must specify a valid log level (DEBUG, INFO, WARN, ERROR, or NONE)")
+	ErrInvalidOverflow     = errors.New("Client - Config must specify a valid overflow policy (DROP_NEWEST, DROP_OLDEST, or BLOCK)")
+	ErrInvalidKeepAlive    = errors.New("Client - Config must specify a valid keepalive mode (BOTH, PING, EMPTY_BINARY, or MESSAGE with a KeepAlivePayload)")
+	ErrInvalidGuard        = errors.New("Client - Config must specify a valid subscription guard (WARN or BLOCK) and a non-negative MaxSubscriptions")
+	ErrInvalidStaleTimeout = errors.New("Client - Config must specify a StaleTimeout longer than the HeartbeatInterval")
+	ErrReloadRestart       = errors.New("Client - ApiKey, Provider, IPAddress, ReorderMaxDelayMs, MaxOverflowBytes, worker count, queue depth, and buffer size changes require a new client")
+)
+
 type Config struct {
-	ApiKey    string
-	Provider  Provider
-	IPAddress string
+	ApiKey              string
+	Provider            Provider
+	IPAddress           string
+	StatsReportInterval int
+	ReorderMaxDelayMs   int
+	MaxOverflowBytes    int64
+	OverflowPolicy      OverflowPolicy
+	KeepAliveMode       KeepAliveMode
+	KeepAlivePayload    string
+	HeartbeatInterval   int
+	StaleTimeout        int
+	MaxSubscriptions    int
+	SubscriptionGuard   SubscriptionGuardMode
+	TradeFilter         string
+	QuoteFilter         string
+	UAFilter            string
+	LogLevel            string
+	Symbols             []string
+	AppIdentifier       string
+	Headers             map[string]string
+	StateFile           string
+	InternSymbols       bool
+	NumWorkers          int
+	ReadQueueDepth      int
+	WriteQueueDepth     int
+	ReadBufferSize      int
+	WriteBufferSize     int
+	DialTimeout         int
+	TokenRefreshAge     int
+	RestPolicy          RestPolicy
+	HTTPClient          *http.Client      `json:"-"`
+	Dialer              *websocket.Dialer `json:"-"`
+}
+
+func (config Config) getLogLevel() (LogLevel, bool) {
+	switch strings.ToUpper(strings.TrimSpace(config.LogLevel)) {
+	case "DEBUG":
+		return LOG_DEBUG, true
+	case "INFO":
+		return LOG_INFO, true
+	case "WARN":
+		return LOG_WARN, true
+	case "ERROR":
+		return LOG_ERROR, true
+	case "NONE":
+		return LOG_NONE, true
+	}
+	return LOG_INFO, false
+}
+
+func (config Config) getLogger() Logger {
+	stdLogger, isStdLogger := defaultLogger.(*StdLogger)
+	if !isStdLogger {
+		return defaultLogger
+	}
+	level, ok := config.getLogLevel()
+	if !ok {
+		level = stdLogger.GetLevel()
+	}
+	return NewStdLogger(level)
+}
+
+func (config Config) getOverflowPolicy() (OverflowPolicy, bool) {
+	switch policy := OverflowPolicy(strings.ToUpper(strings.TrimSpace(string(config.OverflowPolicy)))); policy {
+	case "":
+		return OVERFLOW_DROP_NEWEST, true
+	case OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST, OVERFLOW_BLOCK:
+		return policy, true
+	}
+	return OVERFLOW_DROP_NEWEST, false
+}
+
+func (config Config) getKeepAlive() (keepAlive, bool) {
+	switch mode := KeepAliveMode(strings.ToUpper(strings.TrimSpace(string(config.KeepAliveMode)))); mode {
+	case "":
+		return keepAlive{mode: KEEPALIVE_BOTH}, true
+	case KEEPALIVE_BOTH, KEEPALIVE_PING, KEEPALIVE_EMPTY_BINARY:
+		return keepAlive{mode: mode}, true
+	case KEEPALIVE_MESSAGE:
+		return keepAlive{mode: mode, payload: []byte(config.KeepAlivePayload)}, config.KeepAlivePayload != ""
+	}
+	return keepAlive{mode: KEEPALIVE_BOTH}, false
+}
+
+func (config Config) getSubscriptionGuard() (subscriptionGuard, bool) {
+	switch mode := SubscriptionGuardMode(strings.ToUpper(strings.TrimSpace(string(config.SubscriptionGuard)))); mode {
+	case "":
+		return subscriptionGuard{mode: SUBSCRIPTION_GUARD_WARN, maxSubscriptions: config.MaxSubscriptions}, config.MaxSubscriptions >= 0
+	case SUBSCRIPTION_GUARD_WARN, SUBSCRIPTION_GUARD_BLOCK:
+		return subscriptionGuard{mode: mode, maxSubscriptions: config.MaxSubscriptions}, config.MaxSubscriptions >= 0
+	}
+	return subscriptionGuard{mode: SUBSCRIPTION_GUARD_WARN}, false
+}
+
+func compileFilter[T RuleEvent](expression string) (*Rule[T], error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	return CompileRule[T](expression)
+}
+
+func (config Config) getEquityFilters() (equityFilters, error) {
+	trade, tradeErr := compileFilter[EquityTrade](config.TradeFilter)
+	if tradeErr != nil {
+		return equityFilters{}, tradeErr
+	}
+	quote, quoteErr := compileFilter[EquityQuote](config.QuoteFilter)
+	if quoteErr != nil {
+		return equityFilters{}, quoteErr
+	}
+	return equityFilters{trade: trade, quote: quote}, nil
+}
+
+func (config Config) getOptionFilters() (optionFilters, error) {
+	trade, tradeErr := compileFilter[OptionTrade](config.TradeFilter)
+	if tradeErr != nil {
+		return optionFilters{}, tradeErr
+	}
+	quote, quoteErr := compileFilter[OptionQuote](config.QuoteFilter)
+	if quoteErr != nil {
+		return optionFilters{}, quoteErr
+	}
+	ua, uaErr := compileFilter[OptionUnusualActivity](config.UAFilter)
+	if uaErr != nil {
+		return optionFilters{}, uaErr
+	}
+	return optionFilters{trade: trade, quote: quote, ua: ua}, nil
+}
+
+func (config Config) getOverflowQueue() *overflowQueue {
+	if config.MaxOverflowBytes <= 0 {
+		return nil
+	}
+	return newOverflowQueue(config.MaxOverflowBytes)
+}
+
+func (config Config) getTokenRefreshAge() time.Duration {
+	if config.TokenRefreshAge == 0 {
+		return TOKEN_REFRESH_AGE
+	} else if config.TokenRefreshAge < 0 {
+		return 0
+	}
+	refreshAge := time.Duration(config.TokenRefreshAge) * time.Second
+	if refreshAge > TOKEN_LIFETIME {
+		return TOKEN_LIFETIME
+	}
+	return refreshAge
+}
+
+func (config Config) getDialTimeout() time.Duration {
+	if config.DialTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(config.DialTimeout) * time.Second
+}
+
+func getHTTPClient(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		return http.DefaultClient
+	}
+	return httpClient
+}
+
+func (config Config) getWorkerCount(defaultCount int) int {
+	if config.NumWorkers <= 0 {
+		return defaultCount
+	}
+	return config.NumWorkers
+}
+
+func (config Config) getReadQueueDepth(defaultDepth int) int {
+	if config.ReadQueueDepth <= 0 {
+		return defaultDepth
+	}
+	return config.ReadQueueDepth
+}
+
+func (config Config) getWriteQueueDepth() int {
+	if config.WriteQueueDepth <= 0 {
+		return WRITE_QUEUE_DEPTH
+	}
+	return config.WriteQueueDepth
+}
+
+func (config Config) getReadBufferSize() int {
+	if config.ReadBufferSize <= 0 {
+		return WS_READ_BUFFER_SIZE
+	}
+	return config.ReadBufferSize
+}
+
+func (config Config) getWriteBufferSize() int {
+	if config.WriteBufferSize <= 0 {
+		return WS_WRITE_BUFFER_SIZE
+	}
+	return config.WriteBufferSize
+}
+
+func (config Config) getReorderBuffer() *reorderBuffer {
+	if config.ReorderMaxDelayMs <= 0 {
+		return nil
+	}
+	return newReorderBuffer(time.Duration(config.ReorderMaxDelayMs) * time.Millisecond)
+}
+
+func (config Config) getHeartbeatInterval() time.Duration {
+	if config.HeartbeatInterval <= 0 {
+		return time.Duration(HEARTBEAT_INTERVAL) * time.Second
+	}
+	return time.Duration(config.HeartbeatInterval) * time.Second
+}
+
+func (config Config) getStaleTimeout() (time.Duration, bool) {
+	if config.StaleTimeout <= 0 {
+		return time.Duration(KEEPALIVE_FALLBACK_HEARTBEATS) * config.getHeartbeatInterval(), true
+	}
+	staleTimeout := time.Duration(config.StaleTimeout) * time.Second
+	return staleTimeout, staleTimeout > config.getHeartbeatInterval()
+}
+
+func (config Config) getStatsReportInterval() time.Duration {
+	if config.StatsReportInterval == 0 {
+		return time.Duration(HEARTBEAT_INTERVAL) * time.Second
+	}
+	return time.Duration(config.StatsReportInterval) * time.Second
 }
 
-func (config Config) getAuthUrl() string {
+func (config Config) getAuthUrl() (string, error) {
 	if config.Provider == "OPRA" {
-		return ("https://realtime-options.intrinio.com/auth?api_key=" + config.ApiKey)
+		return ("https://realtime-options.intrinio.com/auth?api_key=" + config.ApiKey), nil
 	} else if config.Provider == "DELAYED_SIP" {
-		return ("https://realtime-delayed-sip.intrinio.com/auth?api_key=" + config.ApiKey)
+		return ("https://realtime-delayed-sip.intrinio.com/auth?api_key=" + config.ApiKey), nil
 	} else if config.Provider == "NASDAQ_BASIC" {
-		return ("https://realtime-nasdaq-basic.intrinio.com/auth?api_key=" + config.ApiKey)
+		return ("https://realtime-nasdaq-basic.intrinio.com/auth?api_key=" + config.ApiKey), nil
 	} else if config.Provider == "IEX" {
-		return ("https://realtime-mx.intrinio.com/auth?api_key=" + config.ApiKey)
+		return ("https://realtime-mx.intrinio.com/auth?api_key=" + config.ApiKey), nil
+	} else if config.Provider == "CBOE_ONE" {
+		return ("https://cboe-one.intrinio.com/auth?api_key=" + config.ApiKey), nil
 	} else if config.Provider == "MANUAL" {
-		return ("http://" + config.IPAddress + "/auth?api_key=" + config.ApiKey)
+		return ("http://" + config.IPAddress + "/auth?api_key=" + config.ApiKey), nil
 	} else {
-		panic("Client - Provider not specified in config")
+		return "", ErrInvalidProvider
 	}
 }
 
-func (config Config) getWSUrl(token string) string {
+func (config Config) getWSUrl(token string) (string, error) {
 	if config.Provider == "OPRA" {
-		return ("wss://realtime-options.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token)
+		return ("wss://realtime-options.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token), nil
 	} else if config.Provider == "DELAYED_SIP" {
-		return ("wss://realtime-delayed-sip.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token)
+		return ("wss://realtime-delayed-sip.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token), nil
 	} else if config.Provider == "NASDAQ_BASIC" {
-		return ("wss://realtime-nasdaq-basic.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token)
+		return ("wss://realtime-nasdaq-basic.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token), nil
 	} else if config.Provider == "IEX" {
-		return ("wss://realtime-mx.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token)
+		return ("wss://realtime-mx.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token), nil
+	} else if config.Provider == "CBOE_ONE" {
+		return ("wss://cboe-one.intrinio.com/socket/websocket?vsn=1.0.0&token=" + token), nil
 	} else if config.Provider == "MANUAL" {
-		return ("ws://" + config.IPAddress + "/socket/websocket?vsn=1.0.0&token=" + token)
+		return ("ws://" + config.IPAddress + "/socket/websocket?vsn=1.0.0&token=" + token), nil
 	} else {
-		panic("Client - Provider not specified in config")
+		return "", ErrInvalidProvider
 	}
 }
 
-func LoadConfig(filename string) Config {
+func (config Config) Validate() error {
+	if config.Provider == "MANUAL" {
+		equityErr := config.validate(false)
+		if (equityErr != nil) && (config.validate(true) == nil) {
+			return nil
+		}
+		return equityErr
+	}
+	return config.validate(config.Provider == "OPRA")
+}
+
+func (config Config) validateFilters(isOptions bool) error {
+	if isOptions {
+		_, filterErr := config.getOptionFilters()
+		return filterErr
+	}
+	_, filterErr := config.getEquityFilters()
+	return filterErr
+}
+
+func (config Config) validate(isOptions bool) error {
+	if strings.TrimSpace(config.ApiKey) == "" {
+		return ErrMissingApiKey
+	}
+	if (config.Provider != "OPRA") &&
+		(config.Provider != "DELAYED_SIP") &&
+		(config.Provider != "NASDAQ_BASIC") &&
+		(config.Provider != "IEX") &&
+		(config.Provider != "CBOE_ONE") &&
+		(config.Provider != "MANUAL") {
+		return ErrInvalidProvider
+	}
+	if (config.Provider == "MANUAL") && (strings.TrimSpace(config.IPAddress) == "") {
+		return ErrMissingIPAddress
+	}
+	if _, ok := config.getLogLevel(); !ok && (strings.TrimSpace(config.LogLevel) != "") {
+		return ErrInvalidLogLevel
+	}
+	if _, ok := config.getOverflowPolicy(); !ok {
+		return ErrInvalidOverflow
+	}
+	if _, ok := config.getKeepAlive(); !ok {
+		return ErrInvalidKeepAlive
+	}
+	if _, ok := config.getSubscriptionGuard(); !ok {
+		return ErrInvalidGuard
+	}
+	if _, ok := config.getStaleTimeout(); !ok {
+		return ErrInvalidStaleTimeout
+	}
+	return config.validateFilters(isOptions)
+}
+
+func LoadConfigFromFile(filename string) (Config, error) {
 	wd, getWdErr := os.Getwd()
 	if getWdErr != nil {
-		panic(getWdErr)
+		return Config{}, getWdErr
 	}
 	filepath := wd + string(os.PathSeparator) + filename
-	log.Printf("Client - Loading application configuration from: %s\n", filepath)
+	defaultLogger.Info("Client - Loading application configuration from: %s\n", filepath)
 	data, readFileErr := os.ReadFile(filepath)
 	if readFileErr != nil {
-		log.Fatal(readFileErr)
+		return Config{}, readFileErr
 	}
 	var config Config
 	unmarshalErr := json.Unmarshal(data, &config)
 	if unmarshalErr != nil {
-		log.Fatal(unmarshalErr)
+		return Config{}, unmarshalErr
 	}
 	if strings.TrimSpace(config.ApiKey) == "" {
 		config.ApiKey = os.Getenv("INTRINIO_API_KEY")
-		if strings.TrimSpace(config.ApiKey) == "" {
-			log.Fatal("Client - A valid API key must be provided (either via the config file or the INTRINIO_API_KEY env variable)")
-		}
 	}
-	if (config.Provider != "OPRA") &&
-		(config.Provider != "DELAYED_SIP") &&
-		(config.Provider != "NASDAQ_BASIC") &&
-		(config.Provider != "IEX") &&
-		(config.Provider != "MANUAL") {
-		log.Fatal("Client - Config must specify a valid provider")
+	return config, config.Validate()
+}
+
+func LoadConfigFromEnv() (Config, error) {
+	config := Config{
+		ApiKey:    os.Getenv("INTRINIO_API_KEY"),
+		Provider:  Provider(os.Getenv("INTRINIO_PROVIDER")),
+		IPAddress: os.Getenv("INTRINIO_IP_ADDRESS"),
 	}
-	if (config.Provider == "MANUAL") && (strings.TrimSpace(config.IPAddress) == "") {
-		log.Fatal("Client - Config must specify an IP address for manual configuration")
+	return config, config.Validate()
+}
+
+func LoadConfig(filename string) Config {
+	config, loadErr := LoadConfigFromFile(filename)
+	if loadErr != nil {
+		log.Fatal(loadErr)
 	}
 	return config
 }
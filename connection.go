@@ -0,0 +1,80 @@
+package intrinio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type ConnectionState uint32
+
+const (
+	CONNECTION_STOPPED ConnectionState = iota
+	CONNECTION_CONNECTING
+	CONNECTION_CONNECTED
+	CONNECTION_RECONNECTING
+)
+
+func (state ConnectionState) String() string {
+	switch state {
+	case CONNECTION_STOPPED:
+		return "STOPPED"
+	case CONNECTION_CONNECTING:
+		return "CONNECTING"
+	case CONNECTION_CONNECTED:
+		return "CONNECTED"
+	case CONNECTION_RECONNECTING:
+		return "RECONNECTING"
+	}
+	return "unknown"
+}
+
+type ConnectionStateChange struct {
+	Previous       ConnectionState
+	State          ConnectionState
+	Time           time.Time
+	ReconnectCount uint64
+	Err            error
+}
+
+func (change ConnectionStateChange) IsConnect() bool {
+	return (change.State == CONNECTION_CONNECTED) && (change.Previous == CONNECTION_CONNECTING)
+}
+
+func (change ConnectionStateChange) IsDisconnect() bool {
+	return change.State == CONNECTION_RECONNECTING
+}
+
+func (change ConnectionStateChange) IsReconnect() bool {
+	return (change.State == CONNECTION_CONNECTED) && (change.Previous == CONNECTION_RECONNECTING)
+}
+
+func (client *Client) GetState() ConnectionState {
+	return ConnectionState(atomic.LoadUint32(&client.connectionState))
+}
+
+func (client *Client) AddConnectionListener(listener func(ConnectionStateChange)) {
+	client.listenersLock.Lock()
+	defer client.listenersLock.Unlock()
+	client.connectionListeners = append(client.connectionListeners, listener)
+}
+
+func (client *Client) setState(state ConnectionState, err error) {
+	previous := ConnectionState(atomic.SwapUint32(&client.connectionState, uint32(state)))
+	if previous == state {
+		return
+	}
+	change := ConnectionStateChange{
+		Previous:       previous,
+		State:          state,
+		Time:           time.Now(),
+		ReconnectCount: atomic.LoadUint64(&client.reconnectCount),
+		Err:            err,
+	}
+	client.logger.Debug("Client - Connection state %s -> %s\n", previous, state)
+	client.listenersLock.Lock()
+	listeners := client.connectionListeners
+	client.listenersLock.Unlock()
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
@@ -0,0 +1,77 @@
+package intrinio
+
+import (
+	"sync"
+	"time"
+)
+
+type ContractDailyStats struct {
+	ContractId       string
+	Open             float32
+	High             float32
+	Low              float32
+	Close            float32
+	PreviousClose    float32
+	HasPreviousClose bool
+	OpenInterest     uint32
+	UpdateTime       time.Time
+}
+
+type ContractDailyTracker struct {
+	lock  sync.Mutex
+	stats map[string]*ContractDailyStats
+}
+
+func NewContractDailyTracker() *ContractDailyTracker {
+	return &ContractDailyTracker{
+		stats: make(map[string]*ContractDailyStats),
+	}
+}
+
+func (tracker *ContractDailyTracker) OnOptionRefresh(refresh OptionRefresh) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	stats, ok := tracker.stats[refresh.ContractId]
+	if !ok {
+		stats = &ContractDailyStats{ContractId: refresh.ContractId}
+		tracker.stats[refresh.ContractId] = stats
+	}
+	stats.Open = refresh.OpenPrice
+	stats.High = refresh.HighPrice
+	stats.Low = refresh.LowPrice
+	stats.Close = refresh.ClosePrice
+	stats.OpenInterest = refresh.OpenInterest
+	stats.UpdateTime = time.Now()
+}
+
+func (tracker *ContractDailyTracker) GetDailyStats(contractId string) (ContractDailyStats, bool) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	if stats, ok := tracker.stats[contractId]; ok {
+		return *stats, true
+	}
+	return ContractDailyStats{}, false
+}
+
+func (tracker *ContractDailyTracker) GetContractIds() []string {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return sortedKeys(tracker.stats)
+}
+
+func (tracker *ContractDailyTracker) ResetSession() {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	for contractId, stats := range tracker.stats {
+		if stats.UpdateTime.IsZero() {
+			delete(tracker.stats, contractId)
+			continue
+		}
+		tracker.stats[contractId] = &ContractDailyStats{
+			ContractId:       contractId,
+			PreviousClose:    stats.Close,
+			HasPreviousClose: true,
+			OpenInterest:     stats.OpenInterest,
+		}
+	}
+}
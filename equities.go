@@ -2,10 +2,159 @@ package intrinio
 
 import (
 	"encoding/binary"
-	"log"
+	"fmt"
 	"math"
 )
 
+type SubProvider uint8
+
+func (sp SubProvider) String() string {
+	switch sp {
+	case SUB_PROVIDER_NONE:
+		return "NONE"
+	case SUB_PROVIDER_CTA_A:
+		return "CTA_A"
+	case SUB_PROVIDER_CTA_B:
+		return "CTA_B"
+	case SUB_PROVIDER_UTP:
+		return "UTP"
+	case SUB_PROVIDER_OTC:
+		return "OTC"
+	case SUB_PROVIDER_NASDAQ_BASIC:
+		return "NASDAQ_BASIC"
+	case SUB_PROVIDER_IEX:
+		return "IEX"
+	case SUB_PROVIDER_CBOE_ONE:
+		return "CBOE_ONE"
+	}
+	return "unknown"
+}
+
+const (
+	SUB_PROVIDER_NONE         SubProvider = 0
+	SUB_PROVIDER_CTA_A        SubProvider = 1
+	SUB_PROVIDER_CTA_B        SubProvider = 2
+	SUB_PROVIDER_UTP          SubProvider = 3
+	SUB_PROVIDER_OTC          SubProvider = 4
+	SUB_PROVIDER_NASDAQ_BASIC SubProvider = 5
+	SUB_PROVIDER_IEX          SubProvider = 6
+	SUB_PROVIDER_CBOE_ONE     SubProvider = 7
+	SUB_PROVIDER_COUNT        int         = 8
+)
+
+type Tape uint8
+
+func (t Tape) String() string {
+	switch t {
+	case TAPE_A:
+		return "A"
+	case TAPE_B:
+		return "B"
+	case TAPE_C:
+		return "C"
+	}
+	return "unknown"
+}
+
+const (
+	TAPE_UNKNOWN Tape = 0
+	TAPE_A       Tape = 'A'
+	TAPE_B       Tape = 'B'
+	TAPE_C       Tape = 'C'
+)
+
+func (sp SubProvider) GetTape() Tape {
+	switch sp {
+	case SUB_PROVIDER_CTA_A:
+		return TAPE_A
+	case SUB_PROVIDER_CTA_B:
+		return TAPE_B
+	case SUB_PROVIDER_UTP:
+		return TAPE_C
+	}
+	return TAPE_UNKNOWN
+}
+
+type MarketCenter rune
+
+func (mc MarketCenter) String() string {
+	switch mc {
+	case MARKET_CENTER_NYSE_AMERICAN:
+		return "NYSE_AMERICAN"
+	case MARKET_CENTER_NASDAQ_BX:
+		return "NASDAQ_BX"
+	case MARKET_CENTER_NYSE_NATIONAL:
+		return "NYSE_NATIONAL"
+	case MARKET_CENTER_FINRA_ADF:
+		return "FINRA_ADF"
+	case MARKET_CENTER_MARKET_INDEPENDENT:
+		return "MARKET_INDEPENDENT"
+	case MARKET_CENTER_MIAX_PEARL:
+		return "MIAX_PEARL"
+	case MARKET_CENTER_NASDAQ_ISE:
+		return "NASDAQ_ISE"
+	case MARKET_CENTER_CBOE_EDGA:
+		return "CBOE_EDGA"
+	case MARKET_CENTER_CBOE_EDGX:
+		return "CBOE_EDGX"
+	case MARKET_CENTER_LTSE:
+		return "LTSE"
+	case MARKET_CENTER_NYSE_CHICAGO:
+		return "NYSE_CHICAGO"
+	case MARKET_CENTER_NYSE:
+		return "NYSE"
+	case MARKET_CENTER_NYSE_ARCA:
+		return "NYSE_ARCA"
+	case MARKET_CENTER_NASDAQ, MARKET_CENTER_NASDAQ_CTA:
+		return "NASDAQ"
+	case MARKET_CENTER_CTS:
+		return "CTS"
+	case MARKET_CENTER_MEMX:
+		return "MEMX"
+	case MARKET_CENTER_IEX:
+		return "IEX"
+	case MARKET_CENTER_CBOE:
+		return "CBOE"
+	case MARKET_CENTER_NASDAQ_PSX:
+		return "NASDAQ_PSX"
+	case MARKET_CENTER_CBOE_BYX:
+		return "CBOE_BYX"
+	case MARKET_CENTER_CBOE_BZX:
+		return "CBOE_BZX"
+	}
+	return "unknown"
+}
+
+const (
+	MARKET_CENTER_UNKNOWN            MarketCenter = 0
+	MARKET_CENTER_NYSE_AMERICAN      MarketCenter = 'A'
+	MARKET_CENTER_NASDAQ_BX          MarketCenter = 'B'
+	MARKET_CENTER_NYSE_NATIONAL      MarketCenter = 'C'
+	MARKET_CENTER_FINRA_ADF          MarketCenter = 'D'
+	MARKET_CENTER_MARKET_INDEPENDENT MarketCenter = 'E'
+	MARKET_CENTER_MIAX_PEARL         MarketCenter = 'H'
+	MARKET_CENTER_NASDAQ_ISE         MarketCenter = 'I'
+	MARKET_CENTER_CBOE_EDGA          MarketCenter = 'J'
+	MARKET_CENTER_CBOE_EDGX          MarketCenter = 'K'
+	MARKET_CENTER_LTSE               MarketCenter = 'L'
+	MARKET_CENTER_NYSE_CHICAGO       MarketCenter = 'M'
+	MARKET_CENTER_NYSE               MarketCenter = 'N'
+	MARKET_CENTER_NYSE_ARCA          MarketCenter = 'P'
+	MARKET_CENTER_NASDAQ             MarketCenter = 'Q'
+	MARKET_CENTER_CTS                MarketCenter = 'S'
+	MARKET_CENTER_NASDAQ_CTA         MarketCenter = 'T'
+	MARKET_CENTER_MEMX               MarketCenter = 'U'
+	MARKET_CENTER_IEX                MarketCenter = 'V'
+	MARKET_CENTER_CBOE               MarketCenter = 'W'
+	MARKET_CENTER_NASDAQ_PSX         MarketCenter = 'X'
+	MARKET_CENTER_CBOE_BYX           MarketCenter = 'Y'
+	MARKET_CENTER_CBOE_BZX           MarketCenter = 'Z'
+)
+
+func (mc MarketCenter) IsTradeReportingFacility() bool {
+	return mc == MARKET_CENTER_FINRA_ADF
+}
+
 type EquityTrade struct {
 	Symbol       string
 	Source       uint8
@@ -15,21 +164,23 @@ type EquityTrade struct {
 	TotalVolume  uint32
 	Timestamp    float64
 	Conditions   string
+	TimestampNs  uint64
 }
 
-func parseEquityTrade(bytes []byte) EquityTrade {
+func parseEquityTrade(bytes []byte, symbols *symbolTable) EquityTrade {
 	symbolLen := bytes[2]
-	symbol := string(bytes[3 : 3+symbolLen])
+	symbol := symbols.get(bytes[3:3+symbolLen], bytesToString)
 	source := bytes[3+symbolLen]
 	marketCenter := rune(binary.LittleEndian.Uint16(bytes[4+symbolLen : 6+symbolLen]))
 	price := math.Float32frombits(binary.LittleEndian.Uint32(bytes[6+symbolLen : 10+symbolLen]))
 	size := binary.LittleEndian.Uint32(bytes[10+symbolLen : 14+symbolLen])
-	timestamp := float64(binary.LittleEndian.Uint64(bytes[14+symbolLen:22+symbolLen])) / 1000000000.0
+	timestampNs := binary.LittleEndian.Uint64(bytes[14+symbolLen : 22+symbolLen])
+	timestamp := float64(timestampNs) / 1000000000.0
 	totalVolume := binary.LittleEndian.Uint32(bytes[22+symbolLen : 26+symbolLen])
 	conditionsLen := bytes[26+symbolLen]
 	conditions := ""
 	if conditionsLen > 0 {
-		conditions = string(bytes[27+symbolLen : 27+symbolLen+conditionsLen])
+		conditions = symbols.get(bytes[27+symbolLen:27+symbolLen+conditionsLen], bytesToString)
 	}
 	return EquityTrade{
 		Symbol:       symbol,
@@ -40,9 +191,22 @@ func parseEquityTrade(bytes []byte) EquityTrade {
 		Timestamp:    timestamp,
 		TotalVolume:  totalVolume,
 		Conditions:   conditions,
+		TimestampNs:  timestampNs,
 	}
 }
 
+func (trade EquityTrade) GetSubProvider() SubProvider {
+	return SubProvider(trade.Source)
+}
+
+func (trade EquityTrade) GetTape() Tape {
+	return trade.GetSubProvider().GetTape()
+}
+
+func (trade EquityTrade) GetMarketCenter() MarketCenter {
+	return MarketCenter(trade.MarketCenter)
+}
+
 type QuoteType uint8
 
 const (
@@ -59,20 +223,22 @@ type EquityQuote struct {
 	Size         uint32
 	Timestamp    float64
 	Conditions   string
+	TimestampNs  uint64
 }
 
-func parseEquityQuote(bytes []byte) EquityQuote {
+func parseEquityQuote(bytes []byte, symbols *symbolTable) EquityQuote {
 	symbolLen := bytes[2]
-	symbol := string(bytes[3 : 3+symbolLen])
+	symbol := symbols.get(bytes[3:3+symbolLen], bytesToString)
 	source := bytes[3+symbolLen]
 	marketCenter := rune(binary.LittleEndian.Uint16(bytes[4+symbolLen : 6+symbolLen]))
 	price := math.Float32frombits(binary.LittleEndian.Uint32(bytes[6+symbolLen : 10+symbolLen]))
 	size := binary.LittleEndian.Uint32(bytes[10+symbolLen : 14+symbolLen])
-	timestamp := float64(binary.LittleEndian.Uint64(bytes[14+symbolLen:22+symbolLen])) / 1000000000.0
+	timestampNs := binary.LittleEndian.Uint64(bytes[14+symbolLen : 22+symbolLen])
+	timestamp := float64(timestampNs) / 1000000000.0
 	conditionsLen := bytes[22+symbolLen]
 	conditions := ""
 	if conditionsLen > 0 {
-		conditions = string(bytes[23+symbolLen : 23+symbolLen+conditionsLen])
+		conditions = symbols.get(bytes[23+symbolLen:23+symbolLen+conditionsLen], bytesToString)
 	}
 	return EquityQuote{
 		Type:         QuoteType(bytes[0]),
@@ -83,40 +249,169 @@ func parseEquityQuote(bytes []byte) EquityQuote {
 		Size:         size,
 		Timestamp:    timestamp,
 		Conditions:   conditions,
+		TimestampNs:  timestampNs,
 	}
 }
 
-func workOnEquities(
-	readChannel <-chan []byte,
-	onTrade func(EquityTrade),
-	onQuote func(EquityQuote)) {
-	select {
-	case data := <-readChannel:
-		count := data[0]
-		startIndex := 1
-		for i := 0; i < int(count); i++ {
-			msgType := data[startIndex]
-			if (msgType == 1) || (msgType == 2) {
-				//endIndex := int(data[startIndex+1])
-				endIndex := startIndex + int(data[startIndex+1])
-				quote := parseEquityQuote(data[startIndex:endIndex])
-				startIndex = endIndex
-				if onQuote != nil {
-					onQuote(quote)
-				}
-			} else if msgType == 0 {
-				endIndex := startIndex + int(data[startIndex+1])
-				trade := parseEquityTrade(data[startIndex:endIndex])
-				startIndex = endIndex
-				if onTrade != nil {
-					onTrade(trade)
-				}
-			} else {
-				log.Printf("Equity Client - Invalid message type: %d", msgType)
+func (quote EquityQuote) GetSubProvider() SubProvider {
+	return SubProvider(quote.Source)
+}
+
+func (quote EquityQuote) GetTape() Tape {
+	return quote.GetSubProvider().GetTape()
+}
+
+func (quote EquityQuote) GetMarketCenter() MarketCenter {
+	return MarketCenter(quote.MarketCenter)
+}
+
+type equityHandlers struct {
+	onTrade func(EquityTrade)
+	onQuote func(EquityQuote)
+}
+
+func (handlers equityHandlers) getWorkerCount() int {
+	workerCount := 2
+	if handlers.onQuote != nil {
+		workerCount += 2
+	}
+	return workerCount
+}
+
+type equityFilters struct {
+	trade *Rule[EquityTrade]
+	quote *Rule[EquityQuote]
+}
+
+func (handlers equityHandlers) filtered(filters equityFilters) equityHandlers {
+	result := handlers
+	if onTrade, rule := handlers.onTrade, filters.trade; (onTrade != nil) && (rule != nil) {
+		result.onTrade = func(trade EquityTrade) {
+			if rule.Match(trade) {
+				onTrade(trade)
 			}
 		}
-	default:
 	}
+	if onQuote, rule := handlers.onQuote, filters.quote; (onQuote != nil) && (rule != nil) {
+		result.onQuote = func(quote EquityQuote) {
+			if rule.Match(quote) {
+				onQuote(quote)
+			}
+		}
+	}
+	return result
+}
+
+type EquityDispatcher interface {
+	DispatchTrade(EquityTrade)
+	DispatchQuote(EquityQuote)
+}
+
+func NewEquityDispatcher(onTrade func(EquityTrade), onQuote func(EquityQuote)) EquityDispatcher {
+	return equityHandlers{onTrade: onTrade, onQuote: onQuote}
+}
+
+func (handlers equityHandlers) DispatchTrade(trade EquityTrade) {
+	if handlers.onTrade != nil {
+		handlers.onTrade(trade)
+	}
+}
+
+func (handlers equityHandlers) DispatchQuote(quote EquityQuote) {
+	if handlers.onQuote != nil {
+		handlers.onQuote(quote)
+	}
+}
+
+func DecodeEquityFrame(data []byte, dispatcher EquityDispatcher) error {
+	return decodeEquityFrame(data, dispatcher, func(SubProvider) bool { return true }, func(eventKind) {}, nil)
+}
+
+func getEquityMsgSize(msg []byte) (int, bool) {
+	headerSize := 23
+	if msg[0] == 0 {
+		headerSize = 27
+	}
+	if len(msg) < 3 {
+		return headerSize, false
+	}
+	size := headerSize + int(msg[2])
+	if len(msg) < size {
+		return size, false
+	}
+	size += int(msg[size-1])
+	return size, len(msg) >= size
+}
+
+func decodeEquityFrame(
+	data []byte,
+	dispatcher EquityDispatcher,
+	acceptSubProvider func(SubProvider) bool,
+	countEvent func(eventKind),
+	symbols *symbolTable) error {
+	if len(data) == 0 {
+		countEvent(invalidEvent)
+		return fmt.Errorf("%w: empty frame", ErrInvalidFrame)
+	}
+	var frameErr error = nil
+	count := int(data[0])
+	startIndex := 1
+	for i := 0; i < count; i++ {
+		if startIndex+2 > len(data) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d exceeds frame length %d", ErrInvalidFrame, i+1, count, startIndex, len(data))
+		}
+		msgType := data[startIndex]
+		msgLength := int(data[startIndex+1])
+		if msgLength < 2 || startIndex+msgLength > len(data) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d declares length %d but frame length is %d", ErrInvalidFrame, i+1, count, startIndex, msgLength, len(data))
+		}
+		endIndex := startIndex + msgLength
+		if msgType <= 2 {
+			if msgSize, ok := getEquityMsgSize(data[startIndex:endIndex]); !ok {
+				countEvent(invalidEvent)
+				return fmt.Errorf("%w: message %d of %d at offset %d needs %d bytes but declares length %d", ErrInvalidFrame, i+1, count, startIndex, msgSize, msgLength)
+			}
+		}
+		if (msgType == 1) || (msgType == 2) {
+			quote := parseEquityQuote(data[startIndex:endIndex], symbols)
+			countEvent(quoteEvent)
+			if acceptSubProvider(quote.GetSubProvider()) {
+				dispatcher.DispatchQuote(quote)
+			}
+		} else if msgType == 0 {
+			trade := parseEquityTrade(data[startIndex:endIndex], symbols)
+			countEvent(tradeEvent)
+			if acceptSubProvider(trade.GetSubProvider()) {
+				dispatcher.DispatchTrade(trade)
+			}
+		} else {
+			countEvent(invalidEvent)
+			if frameErr == nil {
+				frameErr = fmt.Errorf("%w: invalid message type %d", ErrInvalidFrame, msgType)
+			}
+		}
+		startIndex = endIndex
+	}
+	if (frameErr == nil) && (startIndex < len(data)) {
+		frameErr = fmt.Errorf("%w: %d trailing bytes after %d messages", ErrInvalidFrame, len(data)-startIndex, count)
+	}
+	return frameErr
+}
+
+func workOnEquities(
+	data []byte,
+	releaseFrame func([]byte),
+	logger Logger,
+	dispatcher EquityDispatcher,
+	acceptSubProvider func(SubProvider) bool,
+	countEvent func(eventKind),
+	symbols *symbolTable) {
+	if decodeErr := decodeEquityFrame(data, dispatcher, acceptSubProvider, countEvent, symbols); decodeErr != nil {
+		logger.Error("Equity Client - %v\n", decodeErr)
+	}
+	releaseFrame(data)
 }
 
 func composeEquityJoinMsg(
@@ -130,7 +425,6 @@ func composeEquityJoinMsg(
 	message := make([]byte, 0, 11)
 	message = append(message, 74, tradesOnly)
 	message = append(message, []byte(symbol)...)
-	log.Printf("Equity Client - Composed join msg for channel %s\n", symbol)
 	return message
 }
 
@@ -138,6 +432,5 @@ func composeEquityLeaveMsg(symbol string) []byte {
 	message := make([]byte, 0, 10)
 	message = append(message, 76)
 	message = append(message, []byte(symbol)...)
-	log.Printf("Equity Client - Composed leave msg for channel %s\n", symbol)
 	return message
 }
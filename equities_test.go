@@ -0,0 +1,87 @@
+package intrinio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func composeTestEquityTrade(symbol string, conditions string) []byte {
+	msg := []byte{0, 0, byte(len(symbol))}
+	msg = append(msg, symbol...)
+	msg = append(msg, byte(SUB_PROVIDER_UTP))
+	msg = binary.LittleEndian.AppendUint16(msg, 'Q')
+	msg = binary.LittleEndian.AppendUint32(msg, math.Float32bits(101.25))
+	msg = binary.LittleEndian.AppendUint32(msg, 100)
+	msg = binary.LittleEndian.AppendUint64(msg, 1700000000000000000)
+	msg = binary.LittleEndian.AppendUint32(msg, 5000)
+	msg = append(msg, byte(len(conditions)))
+	msg = append(msg, conditions...)
+	msg[1] = byte(len(msg))
+	return msg
+}
+
+func composeTestEquityQuote(symbol string) []byte {
+	msg := []byte{byte(ASK), 0, byte(len(symbol))}
+	msg = append(msg, symbol...)
+	msg = append(msg, byte(SUB_PROVIDER_CTA_A))
+	msg = binary.LittleEndian.AppendUint16(msg, 'N')
+	msg = binary.LittleEndian.AppendUint32(msg, math.Float32bits(101.5))
+	msg = binary.LittleEndian.AppendUint32(msg, 200)
+	msg = binary.LittleEndian.AppendUint64(msg, 1700000000000000000)
+	msg = append(msg, 0)
+	msg[1] = byte(len(msg))
+	return msg
+}
+
+func TestDecodeEquityFrame(t *testing.T) {
+	frame := append([]byte{2}, composeTestEquityTrade("AAPL", "@I")...)
+	frame = append(frame, composeTestEquityQuote("MSFT")...)
+	var trades []EquityTrade
+	var quotes []EquityQuote
+	dispatcher := NewEquityDispatcher(
+		func(trade EquityTrade) { trades = append(trades, trade) },
+		func(quote EquityQuote) { quotes = append(quotes, quote) })
+	if decodeErr := DecodeEquityFrame(frame, dispatcher); decodeErr != nil {
+		t.Fatalf("unexpected error: %v", decodeErr)
+	}
+	if (len(trades) != 1) || (trades[0].Symbol != "AAPL") || (trades[0].Conditions != "@I") || (trades[0].Size != 100) {
+		t.Fatalf("unexpected trades: %+v", trades)
+	}
+	if (len(quotes) != 1) || (quotes[0].Symbol != "MSFT") || (quotes[0].Type != ASK) || (quotes[0].Size != 200) {
+		t.Fatalf("unexpected quotes: %+v", quotes)
+	}
+}
+
+func TestDecodeEquityFrameRejectsMalformedFrames(t *testing.T) {
+	trade := composeTestEquityTrade("AAPL", "@I")
+	quote := composeTestEquityQuote("MSFT")
+	longSymbol := append([]byte{}, trade...)
+	longSymbol[2] = 40
+	longConditions := append([]byte{}, trade...)
+	longConditions[len(trade)-3] = 40
+	shortTrade := append([]byte{}, trade[:20]...)
+	shortTrade[1] = 20
+	shortQuote := append([]byte{}, quote[:10]...)
+	shortQuote[1] = 10
+	frames := map[string][]byte{
+		"empty":              {},
+		"truncated header":   {1, 0},
+		"declared too short": {1, 0, 3, 5},
+		"declared too long":  append([]byte{1}, trade[:len(trade)-1]...),
+		"symbol overrun":     append([]byte{1}, longSymbol...),
+		"conditions overrun": append([]byte{1}, longConditions...),
+		"short trade":        append([]byte{1}, shortTrade...),
+		"short quote":        append([]byte{1}, shortQuote...),
+		"missing message":    append([]byte{2}, trade...),
+		"trailing bytes":     append(append([]byte{1}, trade...), 0),
+		"invalid type":       {1, 9, 2},
+	}
+	dispatcher := NewEquityDispatcher(func(EquityTrade) {}, func(EquityQuote) {})
+	for name, frame := range frames {
+		if decodeErr := DecodeEquityFrame(frame, dispatcher); !errors.Is(decodeErr, ErrInvalidFrame) {
+			t.Errorf("%s: expected ErrInvalidFrame, got %v", name, decodeErr)
+		}
+	}
+}
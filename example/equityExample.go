@@ -45,13 +45,18 @@ func reportEquities(ticker <-chan time.Time) {
 }
 
 func runEquitiesExample() *intrinio.Client {
-	var config intrinio.Config = intrinio.LoadConfig("equities-config.json")
+	config, configErr := intrinio.LoadConfigFromFile("equities-config.json")
+	if configErr != nil {
+		log.Fatal(configErr)
+	}
 	var client *intrinio.Client = intrinio.NewEquitiesClient(config, handleEquityTrade, handleEquityQuote)
-	client.Start()
+	if startErr := client.Start(); startErr != nil {
+		log.Fatal(startErr)
+	}
 	symbols := []string{"AAPL", "MSFT"}
 	//client.Join("GOOG")
 	client.JoinMany(symbols)
-	//client.JoinLobby()
+	//client.JoinLobby(false)
 	var ticker *time.Ticker = time.NewTicker(30 * time.Second)
 	go reportEquities(ticker.C)
 	return client
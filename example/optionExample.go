@@ -71,15 +71,20 @@ func reportOptions(ticker <-chan time.Time) {
 }
 
 func runOptionsExample() *intrinio.Client {
-	var config intrinio.Config = intrinio.LoadConfig("options-config.json")
+	config, configErr := intrinio.LoadConfigFromFile("options-config.json")
+	if configErr != nil {
+		log.Fatal(configErr)
+	}
 	var client *intrinio.Client = intrinio.NewOptionsClient(config, handleOptionTrade, handleOptionQuote, handleOptionRefresh, handleOptionUA)
-	client.Start()
+	if startErr := client.Start(); startErr != nil {
+		log.Fatal(startErr)
+	}
 	//symbols := []string{"SPY_230306C404.00", "SPY_230306C405.00", "SPY_230306C406.00"}
 	//symbols := []string{"SPY", "AAPL", "SPX", "MSFT", "GE", "TSLA"}
 	symbols := []string{"AAPL", "MSFT"}
 	//client.Join("GE")
 	client.JoinMany(symbols)
-	//client.JoinLobby()
+	//client.JoinLobby(false)
 	var ticker *time.Ticker = time.NewTicker(30 * time.Second)
 	go reportOptions(ticker.C)
 	return client
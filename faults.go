@@ -0,0 +1,30 @@
+package intrinio
+
+type FaultInjector interface {
+	InjectAuthFailure() error
+	InjectDialFailure() error
+	InjectReadFailure() error
+	InjectFrameFault(data []byte) []byte
+}
+
+type NoFaultInjector struct{}
+
+func (NoFaultInjector) InjectAuthFailure() error {
+	return nil
+}
+
+func (NoFaultInjector) InjectDialFailure() error {
+	return nil
+}
+
+func (NoFaultInjector) InjectReadFailure() error {
+	return nil
+}
+
+func (NoFaultInjector) InjectFrameFault(data []byte) []byte {
+	return data
+}
+
+func (client *Client) SetFaultInjector(faultInjector FaultInjector) {
+	client.faultInjector = faultInjector
+}
@@ -0,0 +1,45 @@
+package intrinio
+
+import (
+	"sync/atomic"
+)
+
+type subscriptionGuard struct {
+	mode             SubscriptionGuardMode
+	maxSubscriptions int
+}
+
+func (client *Client) allowJoin(symbol string) bool {
+	guard := client.subscriptionGuard.Load().(subscriptionGuard)
+	if guard.maxSubscriptions <= 0 {
+		return true
+	}
+	isLobby := symbol == LOBBY_CHANNEL
+	if !isLobby && (len(client.subscriptions) < guard.maxSubscriptions) {
+		return true
+	}
+	if guard.mode == SUBSCRIPTION_GUARD_BLOCK {
+		atomic.AddUint64(&client.blockedJoinCount, 1)
+		if isLobby {
+			client.logger.Error("Client - Subscription guard blocked the lobby channel (limit: %d channels)\n", guard.maxSubscriptions)
+		} else if !client.guardTripped {
+			client.guardTripped = true
+			client.logger.Error("Client - Subscription limit of %d channels reached, blocking further channels starting with %s\n", guard.maxSubscriptions, symbol)
+		}
+		return false
+	}
+	if isLobby {
+		client.logger.Warn("Client - Joining the lobby channel exceeds the subscription limit of %d channels\n", guard.maxSubscriptions)
+	} else if !client.guardTripped {
+		client.guardTripped = true
+		client.logger.Warn("Client - Subscription limit of %d channels exceeded by %s\n", guard.maxSubscriptions, symbol)
+	}
+	return true
+}
+
+func (client *Client) resetGuard() {
+	guard := client.subscriptionGuard.Load().(subscriptionGuard)
+	if client.guardTripped && (len(client.subscriptions) < guard.maxSubscriptions) {
+		client.guardTripped = false
+	}
+}
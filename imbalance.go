@@ -0,0 +1,117 @@
+package intrinio
+
+import (
+	"sync"
+)
+
+type QuoteImbalance struct {
+	Symbol           string
+	BidSize          uint32
+	AskSize          uint32
+	Imbalance        float64
+	AverageImbalance float64
+	Timestamp        float64
+}
+
+type QuoteImbalanceConfig struct {
+	Alpha float64
+}
+
+var DefaultQuoteImbalanceConfig QuoteImbalanceConfig = QuoteImbalanceConfig{
+	Alpha: 0.1,
+}
+
+type quoteImbalanceState struct {
+	bidSize   uint32
+	askSize   uint32
+	hasBid    bool
+	hasAsk    bool
+	warm      bool
+	average   float64
+	timestamp float64
+}
+
+type QuoteImbalanceTracker struct {
+	config      QuoteImbalanceConfig
+	lock        sync.Mutex
+	states      map[string]*quoteImbalanceState
+	onImbalance func(QuoteImbalance)
+}
+
+func NewQuoteImbalanceTracker(config QuoteImbalanceConfig, onImbalance func(QuoteImbalance)) *QuoteImbalanceTracker {
+	if (config.Alpha <= 0) || (config.Alpha > 1) {
+		config.Alpha = DefaultQuoteImbalanceConfig.Alpha
+	}
+	return &QuoteImbalanceTracker{
+		config:      config,
+		states:      make(map[string]*quoteImbalanceState),
+		onImbalance: onImbalance,
+	}
+}
+
+func getQuoteImbalance(bidSize uint32, askSize uint32) float64 {
+	total := float64(bidSize) + float64(askSize)
+	if total == 0 {
+		return 0.0
+	}
+	return (float64(bidSize) - float64(askSize)) / total
+}
+
+func (state *quoteImbalanceState) toImbalance(symbol string) QuoteImbalance {
+	return QuoteImbalance{
+		Symbol:           symbol,
+		BidSize:          state.bidSize,
+		AskSize:          state.askSize,
+		Imbalance:        getQuoteImbalance(state.bidSize, state.askSize),
+		AverageImbalance: state.average,
+		Timestamp:        state.timestamp,
+	}
+}
+
+func (tracker *QuoteImbalanceTracker) OnEquityQuote(quote EquityQuote) {
+	tracker.lock.Lock()
+	state, ok := tracker.states[quote.Symbol]
+	if !ok {
+		state = &quoteImbalanceState{}
+		tracker.states[quote.Symbol] = state
+	}
+	if quote.Type == BID {
+		state.bidSize = quote.Size
+		state.hasBid = true
+	} else if quote.Type == ASK {
+		state.askSize = quote.Size
+		state.hasAsk = true
+	}
+	state.timestamp = quote.Timestamp
+	if !(state.hasBid && state.hasAsk) {
+		tracker.lock.Unlock()
+		return
+	}
+	imbalance := getQuoteImbalance(state.bidSize, state.askSize)
+	if state.warm {
+		state.average += tracker.config.Alpha * (imbalance - state.average)
+	} else {
+		state.average = imbalance
+		state.warm = true
+	}
+	result := state.toImbalance(quote.Symbol)
+	tracker.lock.Unlock()
+	if tracker.onImbalance != nil {
+		tracker.onImbalance(result)
+	}
+}
+
+func (tracker *QuoteImbalanceTracker) GetImbalance(symbol string) (QuoteImbalance, bool) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	if state, ok := tracker.states[symbol]; ok && state.warm {
+		return state.toImbalance(symbol), true
+	}
+	return QuoteImbalance{}, false
+}
+
+func (tracker *QuoteImbalanceTracker) ResetSession() {
+	tracker.lock.Lock()
+	tracker.states = make(map[string]*quoteImbalanceState)
+	tracker.lock.Unlock()
+}
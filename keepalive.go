@@ -0,0 +1,81 @@
+package intrinio
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const KEEPALIVE_FALLBACK_HEARTBEATS int = 3
+
+type keepAlive struct {
+	mode    KeepAliveMode
+	payload []byte
+}
+
+func (client *Client) setKeepAlive(config Config) {
+	current, _ := config.getKeepAlive()
+	client.keepAlive.Store(current)
+}
+
+func (client *Client) setHeartbeat(config Config) {
+	heartbeatInterval := config.getHeartbeatInterval()
+	staleTimeout, _ := config.getStaleTimeout()
+	atomic.StoreInt64(&client.staleTimeout, int64(staleTimeout))
+	if atomic.SwapInt64(&client.heartbeatInterval, int64(heartbeatInterval)) != int64(heartbeatInterval) && (client.heartbeat != nil) {
+		client.heartbeat.Reset(heartbeatInterval)
+	}
+}
+
+func (client *Client) getHeartbeatInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&client.heartbeatInterval))
+}
+
+func (client *Client) getStaleTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&client.staleTimeout))
+}
+
+func isStale(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (client *Client) GetKeepAliveMode() KeepAliveMode {
+	return client.keepAlive.Load().(keepAlive).mode
+}
+
+func (client *Client) onKeepAliveResponse() {
+	atomic.StoreInt64(&client.lastResponseTime, time.Now().UnixNano())
+}
+
+func (client *Client) isUnresponsive() bool {
+	lastResponseTime := atomic.LoadInt64(&client.lastResponseTime)
+	if lastMessageTime := atomic.LoadInt64(&client.lastMessageTime); lastMessageTime > lastResponseTime {
+		lastResponseTime = lastMessageTime
+	}
+	silence := time.Duration(KEEPALIVE_FALLBACK_HEARTBEATS) * client.getHeartbeatInterval()
+	return (lastResponseTime > 0) && (time.Since(time.Unix(0, lastResponseTime)) > silence)
+}
+
+func (client *Client) writeKeepAlive() {
+	current := client.keepAlive.Load().(keepAlive)
+	if (current.mode != KEEPALIVE_BOTH) && client.isUnresponsive() {
+		client.logger.Warn("Client - No response to %s keepalive, falling back to %s\n", current.mode, KEEPALIVE_BOTH)
+		current = keepAlive{mode: KEEPALIVE_BOTH}
+		client.keepAlive.Store(current)
+	}
+	switch current.mode {
+	case KEEPALIVE_PING:
+		client.wsConn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(time.Second*2))
+	case KEEPALIVE_EMPTY_BINARY:
+		client.wsConn.WriteMessage(websocket.BinaryMessage, []byte{})
+	case KEEPALIVE_MESSAGE:
+		client.wsConn.WriteMessage(websocket.TextMessage, current.payload)
+	default:
+		client.wsConn.WriteMessage(websocket.BinaryMessage, []byte{})
+		client.wsConn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(time.Second*2))
+	}
+}
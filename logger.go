@@ -0,0 +1,131 @@
+package intrinio
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+type LogLevel int32
+
+const (
+	LOG_DEBUG LogLevel = 0
+	LOG_INFO  LogLevel = 1
+	LOG_WARN  LogLevel = 2
+	LOG_ERROR LogLevel = 3
+	LOG_NONE  LogLevel = 4
+)
+
+func (level LogLevel) String() string {
+	switch level {
+	case LOG_DEBUG:
+		return "DEBUG"
+	case LOG_INFO:
+		return "INFO"
+	case LOG_WARN:
+		return "WARN"
+	case LOG_ERROR:
+		return "ERROR"
+	case LOG_NONE:
+		return "NONE"
+	}
+	return "unknown"
+}
+
+type Logger interface {
+	Debug(format string, args ...any)
+	Info(format string, args ...any)
+	Warn(format string, args ...any)
+	Error(format string, args ...any)
+}
+
+type StdLogger struct {
+	level int32
+}
+
+func NewStdLogger(level LogLevel) *StdLogger {
+	return &StdLogger{level: int32(level)}
+}
+
+func (logger *StdLogger) SetLevel(level LogLevel) {
+	atomic.StoreInt32(&logger.level, int32(level))
+}
+
+func (logger *StdLogger) GetLevel() LogLevel {
+	return LogLevel(atomic.LoadInt32(&logger.level))
+}
+
+func (logger *StdLogger) print(level LogLevel, format string, args []any) {
+	if level >= logger.GetLevel() {
+		log.Printf(format, args...)
+	}
+}
+
+func (logger *StdLogger) Debug(format string, args ...any) {
+	logger.print(LOG_DEBUG, format, args)
+}
+
+func (logger *StdLogger) Info(format string, args ...any) {
+	logger.print(LOG_INFO, format, args)
+}
+
+func (logger *StdLogger) Warn(format string, args ...any) {
+	logger.print(LOG_WARN, format, args)
+}
+
+func (logger *StdLogger) Error(format string, args ...any) {
+	logger.print(LOG_ERROR, format, args)
+}
+
+type NoOpLogger struct{}
+
+func (NoOpLogger) Debug(format string, args ...any) {}
+
+func (NoOpLogger) Info(format string, args ...any) {}
+
+func (NoOpLogger) Warn(format string, args ...any) {}
+
+func (NoOpLogger) Error(format string, args ...any) {}
+
+var defaultLogger Logger = NewStdLogger(LOG_INFO)
+
+func SetDefaultLogger(logger Logger) {
+	defaultLogger = logger
+}
+
+type clientLogger struct {
+	current atomic.Pointer[Logger]
+}
+
+func newClientLogger(logger Logger) *clientLogger {
+	wrapper := &clientLogger{}
+	wrapper.set(logger)
+	return wrapper
+}
+
+func (wrapper *clientLogger) set(logger Logger) {
+	wrapper.current.Store(&logger)
+}
+
+func (wrapper *clientLogger) get() Logger {
+	return *wrapper.current.Load()
+}
+
+func (wrapper *clientLogger) Debug(format string, args ...any) {
+	wrapper.get().Debug(format, args...)
+}
+
+func (wrapper *clientLogger) Info(format string, args ...any) {
+	wrapper.get().Info(format, args...)
+}
+
+func (wrapper *clientLogger) Warn(format string, args ...any) {
+	wrapper.get().Warn(format, args...)
+}
+
+func (wrapper *clientLogger) Error(format string, args ...any) {
+	wrapper.get().Error(format, args...)
+}
+
+func (client *Client) SetLogger(logger Logger) {
+	client.logger.set(logger)
+}
@@ -0,0 +1,156 @@
+package intrinio
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrDuplicateClient = errors.New("Client Manager - Only one equities client and one options client may be managed")
+
+type ClientManager struct {
+	equitiesClient *Client
+	optionsClient  *Client
+}
+
+func (client *Client) isOptionsClient() bool {
+	_, isOptions := client.filters.Load().(optionFilters)
+	return isOptions
+}
+
+func isOptionContractId(symbol string) bool {
+	return (len(symbol) >= 13) && (strings.IndexByte(symbol, '_') > 0)
+}
+
+func NewClientManager(clients ...*Client) (*ClientManager, error) {
+	manager := &ClientManager{}
+	for _, client := range clients {
+		if client.isOptionsClient() {
+			if manager.optionsClient != nil {
+				return nil, ErrDuplicateClient
+			}
+			manager.optionsClient = client
+		} else {
+			if manager.equitiesClient != nil {
+				return nil, ErrDuplicateClient
+			}
+			manager.equitiesClient = client
+		}
+	}
+	return manager, nil
+}
+
+func (manager *ClientManager) GetEquitiesClient() *Client {
+	return manager.equitiesClient
+}
+
+func (manager *ClientManager) GetOptionsClient() *Client {
+	return manager.optionsClient
+}
+
+func (manager *ClientManager) GetClient(symbol string) *Client {
+	if isOptionContractId(strings.TrimSpace(symbol)) || (manager.equitiesClient == nil) {
+		return manager.optionsClient
+	}
+	return manager.equitiesClient
+}
+
+func (manager *ClientManager) getClients() []*Client {
+	clients := make([]*Client, 0, 2)
+	if manager.equitiesClient != nil {
+		clients = append(clients, manager.equitiesClient)
+	}
+	if manager.optionsClient != nil {
+		clients = append(clients, manager.optionsClient)
+	}
+	return clients
+}
+
+func (manager *ClientManager) Start() error {
+	for _, client := range manager.getClients() {
+		if startErr := client.Start(); startErr != nil {
+			for _, started := range manager.getClients() {
+				if started == client {
+					break
+				}
+				started.Stop()
+			}
+			return startErr
+		}
+	}
+	return nil
+}
+
+func (manager *ClientManager) Stop() {
+	for _, client := range manager.getClients() {
+		client.Stop()
+	}
+}
+
+func (manager *ClientManager) Join(symbol string) bool {
+	if client := manager.GetClient(symbol); client != nil {
+		client.Join(symbol)
+		return true
+	}
+	manager.logNoClient(symbol)
+	return false
+}
+
+func (manager *ClientManager) JoinMany(symbols []string) {
+	for _, symbol := range symbols {
+		manager.Join(symbol)
+	}
+}
+
+func (manager *ClientManager) JoinOptionChain(symbol string) bool {
+	if manager.optionsClient != nil {
+		manager.optionsClient.Join(symbol)
+		return true
+	}
+	manager.logNoClient(symbol)
+	return false
+}
+
+func (manager *ClientManager) JoinUnderlying(ticker string) bool {
+	ticker = strings.TrimSpace(ticker)
+	if (ticker == "") || isOptionContractId(ticker) {
+		defaultLogger.Warn("Client Manager - Invalid underlying ticker: %s\n", ticker)
+		return false
+	}
+	joined := false
+	for _, client := range manager.getClients() {
+		client.Join(ticker)
+		joined = true
+	}
+	if !joined {
+		manager.logNoClient(ticker)
+	}
+	return joined
+}
+
+func (manager *ClientManager) JoinManyUnderlyings(tickers []string) {
+	for _, ticker := range tickers {
+		manager.JoinUnderlying(ticker)
+	}
+}
+
+func (manager *ClientManager) Leave(symbol string) {
+	for _, client := range manager.getClients() {
+		client.Leave(symbol)
+	}
+}
+
+func (manager *ClientManager) LeaveMany(symbols []string) {
+	for _, symbol := range symbols {
+		manager.Leave(symbol)
+	}
+}
+
+func (manager *ClientManager) LeaveAll() {
+	for _, client := range manager.getClients() {
+		client.LeaveAll()
+	}
+}
+
+func (manager *ClientManager) logNoClient(symbol string) {
+	defaultLogger.Warn("Client Manager - No client available for %s\n", symbol)
+}
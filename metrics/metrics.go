@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	intrinio "github.com/intrinio/intrinio-realtime-go-sdk"
+)
+
+const CONTENT_TYPE string = "text/plain; version=0.0.4; charset=utf-8"
+
+type metricType string
+
+const (
+	counter metricType = "counter"
+	gauge   metricType = "gauge"
+)
+
+type metric struct {
+	name       string
+	help       string
+	metricType metricType
+	label      string
+	values     func(intrinio.StatsReport) map[string]float64
+}
+
+func single(fn func(intrinio.StatsReport) float64) func(intrinio.StatsReport) map[string]float64 {
+	return func(report intrinio.StatsReport) map[string]float64 {
+		return map[string]float64{"": fn(report)}
+	}
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1.0
+	}
+	return 0.0
+}
+
+var definitions []metric = []metric{
+	{
+		name:       "intrinio_connected",
+		help:       "Whether the websocket connection is currently open (1) or not (0).",
+		metricType: gauge,
+		values:     single(func(r intrinio.StatsReport) float64 { return boolValue(r.Connected) }),
+	},
+	{
+		name:       "intrinio_data_messages_total",
+		help:       "Binary data frames received.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.DataMsgCount) }),
+	},
+	{
+		name:       "intrinio_text_messages_total",
+		help:       "Text messages received.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.TextMsgCount) }),
+	},
+	{
+		name:       "intrinio_received_bytes_total",
+		help:       "Bytes received over the websocket.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.BytesReceived) }),
+	},
+	{
+		name:       "intrinio_events_total",
+		help:       "Events decoded from data frames, by event type.",
+		metricType: counter,
+		label:      "type",
+		values: func(r intrinio.StatsReport) map[string]float64 {
+			return map[string]float64{
+				"trade":            float64(r.TradeCount),
+				"quote":            float64(r.QuoteCount),
+				"refresh":          float64(r.RefreshCount),
+				"unusual_activity": float64(r.UnusualActivityCount),
+			}
+		},
+	},
+	{
+		name:       "intrinio_parse_errors_total",
+		help:       "Events with an invalid message type.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.ParseErrorCount) }),
+	},
+	{
+		name:       "intrinio_queue_depth",
+		help:       "Frames waiting in the read queue.",
+		metricType: gauge,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.QueueDepth) }),
+	},
+	{
+		name:       "intrinio_queue_high_watermark",
+		help:       "Highest read queue depth seen.",
+		metricType: gauge,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.QueueHighWatermark) }),
+	},
+	{
+		name:       "intrinio_overflow_depth",
+		help:       "Frames waiting in the overflow buffer.",
+		metricType: gauge,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.OverflowDepth) }),
+	},
+	{
+		name:       "intrinio_overflow_bytes",
+		help:       "Bytes held in the overflow buffer.",
+		metricType: gauge,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.OverflowBytes) }),
+	},
+	{
+		name:       "intrinio_reconnects_total",
+		help:       "Websocket reconnects.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.ReconnectCount) }),
+	},
+	{
+		name:       "intrinio_dropped_frames_total",
+		help:       "Frames dropped because the read queue was full.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.DroppedFrameCount) }),
+	},
+	{
+		name:       "intrinio_dropped_events_total",
+		help:       "Events contained in dropped frames.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.DroppedEventCount) }),
+	},
+	{
+		name:       "intrinio_late_events_total",
+		help:       "Events delivered out of order by the reorder buffer.",
+		metricType: counter,
+		values:     single(func(r intrinio.StatsReport) float64 { return float64(r.LateEventCount) }),
+	},
+}
+
+type Exporter struct {
+	lock    sync.Mutex
+	clients map[string]*intrinio.Client
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{
+		clients: make(map[string]*intrinio.Client),
+	}
+}
+
+func (exporter *Exporter) Register(name string, client *intrinio.Client) {
+	exporter.lock.Lock()
+	exporter.clients[name] = client
+	exporter.lock.Unlock()
+}
+
+func (exporter *Exporter) Unregister(name string) {
+	exporter.lock.Lock()
+	delete(exporter.clients, name)
+	exporter.lock.Unlock()
+}
+
+func (exporter *Exporter) collect() ([]string, map[string]intrinio.StatsReport) {
+	exporter.lock.Lock()
+	defer exporter.lock.Unlock()
+	names := make([]string, 0, len(exporter.clients))
+	reports := make(map[string]intrinio.StatsReport, len(exporter.clients))
+	for name, client := range exporter.clients {
+		names = append(names, name)
+		reports[name] = client.GetStats()
+	}
+	sort.Strings(names)
+	return names, reports
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(value)
+}
+
+func (exporter *Exporter) WriteTo(w io.Writer) (int64, error) {
+	names, reports := exporter.collect()
+	writer := bufio.NewWriter(w)
+	var written int64 = 0
+	write := func(format string, args ...any) {
+		n, _ := fmt.Fprintf(writer, format, args...)
+		written += int64(n)
+	}
+	for _, m := range definitions {
+		write("# HELP %s %s\n", m.name, m.help)
+		write("# TYPE %s %s\n", m.name, m.metricType)
+		for _, name := range names {
+			values := m.values(reports[name])
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				value := strconv.FormatFloat(values[key], 'g', -1, 64)
+				if m.label == "" {
+					write("%s{client=\"%s\"} %s\n", m.name, escapeLabel(name), value)
+				} else {
+					write("%s{client=\"%s\",%s=\"%s\"} %s\n", m.name, escapeLabel(name), m.label, escapeLabel(key), value)
+				}
+			}
+		}
+	}
+	return written, writer.Flush()
+}
+
+func (exporter *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	exporter.WriteTo(w)
+}
+
+func (exporter *Exporter) Handler() http.Handler {
+	return exporter
+}
@@ -3,7 +3,6 @@ package intrinio
 import (
 	"encoding/binary"
 	"fmt"
-	"log"
 	"math"
 	"strings"
 	"time"
@@ -81,12 +80,19 @@ const (
 
 var priceTypeDivisorTable [16]float64 = [16]float64{1.0, 10.0, 100.0, 1000.0, 10000.0, 100000.0, 1000000.0, 10000000.0, 100000000.0, 1000000000.0, 512.0, 0.0, 0.0, 0.0, 0.0, math.NaN()}
 
+func PriceTypeDivisor(priceType uint8) float64 {
+	if int(priceType) >= len(priceTypeDivisorTable) {
+		return math.NaN()
+	}
+	return priceTypeDivisorTable[priceType]
+}
+
 func extractUInt64Price(priceBytes []byte, priceType uint8) float32 {
-	return float32(float64(binary.LittleEndian.Uint64(priceBytes)) / priceTypeDivisorTable[priceType])
+	return float32(float64(binary.LittleEndian.Uint64(priceBytes)) / PriceTypeDivisor(priceType))
 }
 
 func extractUInt32Price(priceBytes []byte, priceType uint8) float32 {
-	return float32(float64(binary.LittleEndian.Uint32(priceBytes)) / priceTypeDivisorTable[priceType])
+	return float32(float64(binary.LittleEndian.Uint32(priceBytes)) / PriceTypeDivisor(priceType))
 }
 
 func scaleTimestamp(timestamp uint64) float64 {
@@ -111,6 +117,24 @@ func convertOldContractIdToNew(oldContractId string) string {
 	return fmt.Sprintf(`%s_%s%c%s.%s`, symbol, exp, pc, whole, part)
 }
 
+func isValidOptionContract(newContractBytes []byte) bool {
+	indexOfUnderscore := 0
+	for indexOfUnderscore < len(newContractBytes) && newContractBytes[indexOfUnderscore] != '_' {
+		indexOfUnderscore++
+	}
+	indexOfPC := indexOfUnderscore + 7
+	indexOfDecimal := len(newContractBytes) - 2
+	for indexOfDecimal > indexOfPC && newContractBytes[indexOfDecimal] != '.' {
+		indexOfDecimal--
+	}
+	return (indexOfUnderscore > 0) &&
+		(indexOfUnderscore <= 6) &&
+		(indexOfDecimal > indexOfPC) &&
+		(newContractBytes[indexOfDecimal] == '.') &&
+		(indexOfDecimal-indexOfPC <= 6) &&
+		(len(newContractBytes)-indexOfDecimal <= 5)
+}
+
 func extractOldContractId(newContractBytes []byte) string {
 	oldContractBytes := [21]byte{'_', '_', '_', '_', '_', '_', '0', '0', '0', '0', '0', '0', 'X', '0', '0', '0', '0', '0', '0', '0', '0'}
 	i := 0
@@ -156,6 +180,17 @@ type OptionTrade struct {
 	BidPriceAtExecution        float32
 	UnderlyingPriceAtExecution float32
 	Timestamp                  float64
+	TimestampNs                uint64
+	PriceType                  uint8
+	UnderlyingPriceType        uint8
+}
+
+func (trade OptionTrade) GetPriceDivisor() float64 {
+	return PriceTypeDivisor(trade.PriceType)
+}
+
+func (trade OptionTrade) GetUnderlyingPriceDivisor() float64 {
+	return PriceTypeDivisor(trade.UnderlyingPriceType)
 }
 
 func (trade OptionTrade) GetStrikePrice() float32 {
@@ -174,11 +209,11 @@ func (trade OptionTrade) IsCall() bool {
 
 func (trade OptionTrade) GetExpirationDate() time.Time {
 	if loadLocationErr != nil {
-		log.Printf("Client - Failure to load time location - %v\n", loadLocationErr)
+		defaultLogger.Error("Client - Failure to load time location - %v\n", loadLocationErr)
 	}
 	time, err := time.ParseInLocation(TIME_FORMAT, trade.ContractId[6:12], newYork)
 	if err != nil {
-		log.Printf("Client - Failure to parse expiration date from: %s - %v\n", trade.ContractId, err)
+		defaultLogger.Error("Client - Failure to parse expiration date from: %s - %v\n", trade.ContractId, err)
 	}
 	return time
 }
@@ -187,28 +222,37 @@ func (trade OptionTrade) GetUnderlyingSymbol() string {
 	return strings.TrimRight(trade.ContractId[0:6], "_")
 }
 
-func parseOptionTrade(bytes []byte) OptionTrade {
+func parseOptionTrade(bytes []byte, symbols *symbolTable) OptionTrade {
 	return OptionTrade{
-		ContractId:                 extractOldContractId(bytes[1:(1 + bytes[0])]),
+		ContractId:                 symbols.get(bytes[1:(1+bytes[0])], extractOldContractId),
 		Price:                      extractUInt32Price(bytes[25:29], bytes[23]),
 		Size:                       binary.LittleEndian.Uint32(bytes[29:33]),
 		Timestamp:                  scaleTimestamp(binary.LittleEndian.Uint64(bytes[33:41])),
+		TimestampNs:                binary.LittleEndian.Uint64(bytes[33:41]),
 		TotalVolume:                binary.LittleEndian.Uint64(bytes[41:49]),
 		AskPriceAtExecution:        extractUInt32Price(bytes[49:53], bytes[23]),
 		BidPriceAtExecution:        extractUInt32Price(bytes[53:57], bytes[23]),
 		UnderlyingPriceAtExecution: extractUInt32Price(bytes[57:61], bytes[24]),
 		Qualifiers:                 [4]byte(bytes[61:65]),
 		Exchange:                   Exchange(bytes[65]),
+		PriceType:                  bytes[23],
+		UnderlyingPriceType:        bytes[24],
 	}
 }
 
 type OptionQuote struct {
-	ContractId string
-	AskPrice   float32
-	BidPrice   float32
-	AskSize    uint32
-	BidSize    uint32
-	Timestamp  float64
+	ContractId  string
+	AskPrice    float32
+	BidPrice    float32
+	AskSize     uint32
+	BidSize     uint32
+	Timestamp   float64
+	TimestampNs uint64
+	PriceType   uint8
+}
+
+func (quote OptionQuote) GetPriceDivisor() float64 {
+	return PriceTypeDivisor(quote.PriceType)
 }
 
 func (quote OptionQuote) GetStrikePrice() float32 {
@@ -227,11 +271,11 @@ func (quote OptionQuote) IsCall() bool {
 
 func (quote OptionQuote) GetExpirationDate() time.Time {
 	if loadLocationErr != nil {
-		log.Printf("Client - Failure to load time location - %v\n", loadLocationErr)
+		defaultLogger.Error("Client - Failure to load time location - %v\n", loadLocationErr)
 	}
 	time, err := time.ParseInLocation(TIME_FORMAT, quote.ContractId[6:12], newYork)
 	if err != nil {
-		log.Printf("Client - Failure to parse expiration date from: %s - %v\n", quote.ContractId, err)
+		defaultLogger.Error("Client - Failure to parse expiration date from: %s - %v\n", quote.ContractId, err)
 	}
 	return time
 }
@@ -240,14 +284,16 @@ func (quote OptionQuote) GetUnderlyingSymbol() string {
 	return strings.TrimRight(quote.ContractId[0:6], "_")
 }
 
-func parseOptionQuote(bytes []byte) OptionQuote {
+func parseOptionQuote(bytes []byte, symbols *symbolTable) OptionQuote {
 	return OptionQuote{
-		ContractId: extractOldContractId(bytes[1:(1 + bytes[0])]),
-		AskPrice:   extractUInt32Price(bytes[24:28], bytes[23]),
-		AskSize:    binary.LittleEndian.Uint32(bytes[28:32]),
-		BidPrice:   extractUInt32Price(bytes[32:36], bytes[23]),
-		BidSize:    binary.LittleEndian.Uint32(bytes[36:40]),
-		Timestamp:  scaleTimestamp(binary.LittleEndian.Uint64(bytes[40:48])),
+		ContractId:  symbols.get(bytes[1:(1+bytes[0])], extractOldContractId),
+		AskPrice:    extractUInt32Price(bytes[24:28], bytes[23]),
+		AskSize:     binary.LittleEndian.Uint32(bytes[28:32]),
+		BidPrice:    extractUInt32Price(bytes[32:36], bytes[23]),
+		BidSize:     binary.LittleEndian.Uint32(bytes[36:40]),
+		Timestamp:   scaleTimestamp(binary.LittleEndian.Uint64(bytes[40:48])),
+		TimestampNs: binary.LittleEndian.Uint64(bytes[40:48]),
+		PriceType:   bytes[23],
 	}
 }
 
@@ -258,6 +304,11 @@ type OptionRefresh struct {
 	ClosePrice   float32
 	HighPrice    float32
 	LowPrice     float32
+	PriceType    uint8
+}
+
+func (refresh OptionRefresh) GetPriceDivisor() float64 {
+	return PriceTypeDivisor(refresh.PriceType)
 }
 
 func (refresh OptionRefresh) GetStrikePrice() float32 {
@@ -276,11 +327,11 @@ func (refresh OptionRefresh) IsCall() bool {
 
 func (refresh OptionRefresh) GetExpirationDate() time.Time {
 	if loadLocationErr != nil {
-		log.Printf("Client - Failure to load time location - %v\n", loadLocationErr)
+		defaultLogger.Error("Client - Failure to load time location - %v\n", loadLocationErr)
 	}
 	time, err := time.ParseInLocation(TIME_FORMAT, refresh.ContractId[6:12], newYork)
 	if err != nil {
-		log.Printf("Client - Failure to parse expiration date from: %s - %v\n", refresh.ContractId, err)
+		defaultLogger.Error("Client - Failure to parse expiration date from: %s - %v\n", refresh.ContractId, err)
 	}
 	return time
 }
@@ -289,14 +340,15 @@ func (refresh OptionRefresh) GetUnderlyingSymbol() string {
 	return strings.TrimRight(refresh.ContractId[0:6], "_")
 }
 
-func parseOptionRefresh(bytes []byte) OptionRefresh {
+func parseOptionRefresh(bytes []byte, symbols *symbolTable) OptionRefresh {
 	return OptionRefresh{
-		ContractId:   extractOldContractId(bytes[1:(1 + bytes[0])]),
+		ContractId:   symbols.get(bytes[1:(1+bytes[0])], extractOldContractId),
 		OpenInterest: binary.LittleEndian.Uint32(bytes[24:28]),
 		OpenPrice:    extractUInt32Price(bytes[28:32], bytes[23]),
 		ClosePrice:   extractUInt32Price(bytes[32:36], bytes[23]),
 		HighPrice:    extractUInt32Price(bytes[36:40], bytes[23]),
 		LowPrice:     extractUInt32Price(bytes[40:44], bytes[23]),
+		PriceType:    bytes[23],
 	}
 }
 
@@ -328,6 +380,17 @@ type OptionUnusualActivity struct {
 	BidPriceAtExecution        float32
 	UnderlyingPriceAtExecution float32
 	Timestamp                  float64
+	TimestampNs                uint64
+	PriceType                  uint8
+	UnderlyingPriceType        uint8
+}
+
+func (ua OptionUnusualActivity) GetPriceDivisor() float64 {
+	return PriceTypeDivisor(ua.PriceType)
+}
+
+func (ua OptionUnusualActivity) GetUnderlyingPriceDivisor() float64 {
+	return PriceTypeDivisor(ua.UnderlyingPriceType)
 }
 
 func (ua OptionUnusualActivity) GetStrikePrice() float32 {
@@ -346,11 +409,11 @@ func (ua OptionUnusualActivity) IsCall() bool {
 
 func (ua OptionUnusualActivity) GetExpirationDate() time.Time {
 	if loadLocationErr != nil {
-		log.Printf("Client - Failure to load time location - %v\n", loadLocationErr)
+		defaultLogger.Error("Client - Failure to load time location - %v\n", loadLocationErr)
 	}
 	time, err := time.ParseInLocation(TIME_FORMAT, ua.ContractId[6:12], newYork)
 	if err != nil {
-		log.Printf("Client - Failure to parse expiration date from: %s - %v\n", ua.ContractId, err)
+		defaultLogger.Error("Client - Failure to parse expiration date from: %s - %v\n", ua.ContractId, err)
 	}
 	return time
 }
@@ -359,9 +422,9 @@ func (ua OptionUnusualActivity) GetUnderlyingSymbol() string {
 	return strings.TrimRight(ua.ContractId[0:6], "_")
 }
 
-func parseOptionUA(bytes []byte) OptionUnusualActivity {
+func parseOptionUA(bytes []byte, symbols *symbolTable) OptionUnusualActivity {
 	return OptionUnusualActivity{
-		ContractId:                 extractOldContractId(bytes[1:(1 + bytes[0])]),
+		ContractId:                 symbols.get(bytes[1:(1+bytes[0])], extractOldContractId),
 		Type:                       UAType(bytes[22]),
 		Sentiment:                  UASentiment(bytes[23]),
 		TotalValue:                 extractUInt64Price(bytes[26:34], bytes[24]),
@@ -371,51 +434,192 @@ func parseOptionUA(bytes []byte) OptionUnusualActivity {
 		BidPriceAtExecution:        extractUInt32Price(bytes[46:50], bytes[24]),
 		UnderlyingPriceAtExecution: extractUInt32Price(bytes[50:54], bytes[25]),
 		Timestamp:                  scaleTimestamp(binary.LittleEndian.Uint64(bytes[54:62])),
+		TimestampNs:                binary.LittleEndian.Uint64(bytes[54:62]),
+		PriceType:                  bytes[24],
+		UnderlyingPriceType:        bytes[25],
 	}
 }
 
-func workOnOptions(
-	readChannel <-chan []byte,
+type optionHandlers struct {
+	onTrade           func(OptionTrade)
+	onQuote           func(OptionQuote)
+	onRefresh         func(OptionRefresh)
+	onUnusualActivity func(OptionUnusualActivity)
+}
+
+func (handlers optionHandlers) getWorkerCount() int {
+	workerCount := 1
+	if handlers.onTrade != nil {
+		workerCount++
+	}
+	if handlers.onQuote != nil {
+		workerCount += 8
+	}
+	return workerCount
+}
+
+type optionFilters struct {
+	trade *Rule[OptionTrade]
+	quote *Rule[OptionQuote]
+	ua    *Rule[OptionUnusualActivity]
+}
+
+func (handlers optionHandlers) filtered(filters optionFilters) optionHandlers {
+	result := handlers
+	if onTrade, rule := handlers.onTrade, filters.trade; (onTrade != nil) && (rule != nil) {
+		result.onTrade = func(trade OptionTrade) {
+			if rule.Match(trade) {
+				onTrade(trade)
+			}
+		}
+	}
+	if onQuote, rule := handlers.onQuote, filters.quote; (onQuote != nil) && (rule != nil) {
+		result.onQuote = func(quote OptionQuote) {
+			if rule.Match(quote) {
+				onQuote(quote)
+			}
+		}
+	}
+	if onUnusualActivity, rule := handlers.onUnusualActivity, filters.ua; (onUnusualActivity != nil) && (rule != nil) {
+		result.onUnusualActivity = func(ua OptionUnusualActivity) {
+			if rule.Match(ua) {
+				onUnusualActivity(ua)
+			}
+		}
+	}
+	return result
+}
+
+func getOptionMsgSize(msgType uint8) int {
+	switch {
+	case msgType == 0:
+		return OPTION_TRADE_MSG_SIZE
+	case msgType == 1:
+		return OPTION_QUOTE_MSG_SIZE
+	case msgType == 2:
+		return OPTION_REFRESH_MSG_SIZE
+	default:
+		return OPTION_UA_MSG_SIZE
+	}
+}
+
+type OptionDispatcher interface {
+	DispatchTrade(OptionTrade)
+	DispatchQuote(OptionQuote)
+	DispatchRefresh(OptionRefresh)
+	DispatchUnusualActivity(OptionUnusualActivity)
+}
+
+func NewOptionDispatcher(
 	onTrade func(OptionTrade),
 	onQuote func(OptionQuote),
 	onRefresh func(OptionRefresh),
-	onUA func(OptionUnusualActivity)) {
-	select {
-	case data := <-readChannel:
-		count := data[0]
-		startIndex := 1
-		for i := 0; i < int(count); i++ {
-			msgType := data[startIndex+1+MAX_OPTION_SYMBOL_SIZE]
-			if msgType == 1 {
-				quote := parseOptionQuote(data[startIndex:(startIndex + OPTION_QUOTE_MSG_SIZE)])
-				startIndex = startIndex + OPTION_QUOTE_MSG_SIZE
-				if onQuote != nil {
-					onQuote(quote)
-				}
-			} else if msgType == 0 {
-				trade := parseOptionTrade(data[startIndex:(startIndex + OPTION_TRADE_MSG_SIZE)])
-				startIndex = startIndex + OPTION_TRADE_MSG_SIZE
-				if onTrade != nil {
-					onTrade(trade)
-				}
-			} else if msgType > 2 {
-				ua := parseOptionUA(data[startIndex:(startIndex + OPTION_UA_MSG_SIZE)])
-				startIndex = startIndex + OPTION_UA_MSG_SIZE
-				if onUA != nil {
-					onUA(ua)
-				}
-			} else if msgType == 2 {
-				refresh := parseOptionRefresh(data[startIndex:(startIndex + OPTION_REFRESH_MSG_SIZE)])
-				startIndex = startIndex + OPTION_REFRESH_MSG_SIZE
-				if onRefresh != nil {
-					onRefresh(refresh)
-				}
-			} else {
-				log.Printf("Option Client - Invalid message type: %d", msgType)
-			}
+	onUnusualActivity func(OptionUnusualActivity)) OptionDispatcher {
+	return optionHandlers{
+		onTrade:           onTrade,
+		onQuote:           onQuote,
+		onRefresh:         onRefresh,
+		onUnusualActivity: onUnusualActivity,
+	}
+}
+
+func (handlers optionHandlers) DispatchTrade(trade OptionTrade) {
+	if handlers.onTrade != nil {
+		handlers.onTrade(trade)
+	}
+}
+
+func (handlers optionHandlers) DispatchQuote(quote OptionQuote) {
+	if handlers.onQuote != nil {
+		handlers.onQuote(quote)
+	}
+}
+
+func (handlers optionHandlers) DispatchRefresh(refresh OptionRefresh) {
+	if handlers.onRefresh != nil {
+		handlers.onRefresh(refresh)
+	}
+}
+
+func (handlers optionHandlers) DispatchUnusualActivity(ua OptionUnusualActivity) {
+	if handlers.onUnusualActivity != nil {
+		handlers.onUnusualActivity(ua)
+	}
+}
+
+func DecodeOptionFrame(data []byte, dispatcher OptionDispatcher) error {
+	return decodeOptionFrame(data, dispatcher, func(eventKind) {}, nil)
+}
+
+func decodeOptionFrame(
+	data []byte,
+	dispatcher OptionDispatcher,
+	countEvent func(eventKind),
+	symbols *symbolTable) error {
+	if len(data) == 0 {
+		countEvent(invalidEvent)
+		return fmt.Errorf("%w: empty frame", ErrInvalidFrame)
+	}
+	count := int(data[0])
+	startIndex := 1
+	for i := 0; i < count; i++ {
+		if startIndex+2+MAX_OPTION_SYMBOL_SIZE > len(data) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d exceeds frame length %d", ErrInvalidFrame, i+1, count, startIndex, len(data))
 		}
-	default:
+		msgType := data[startIndex+1+MAX_OPTION_SYMBOL_SIZE]
+		if msgSize := getOptionMsgSize(msgType); startIndex+msgSize > len(data) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d needs %d bytes but frame length is %d", ErrInvalidFrame, i+1, count, startIndex, msgSize, len(data))
+		}
+		contractLength := int(data[startIndex])
+		if (contractLength == 0) || (contractLength > MAX_OPTION_SYMBOL_SIZE) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d declares contract length %d", ErrInvalidFrame, i+1, count, startIndex, contractLength)
+		}
+		if contract := data[(startIndex + 1):(startIndex + 1 + contractLength)]; !isValidOptionContract(contract) {
+			countEvent(invalidEvent)
+			return fmt.Errorf("%w: message %d of %d at offset %d has malformed contract %q", ErrInvalidFrame, i+1, count, startIndex, contract)
+		}
+		if msgType == 1 {
+			quote := parseOptionQuote(data[startIndex:(startIndex+OPTION_QUOTE_MSG_SIZE)], symbols)
+			startIndex = startIndex + OPTION_QUOTE_MSG_SIZE
+			countEvent(quoteEvent)
+			dispatcher.DispatchQuote(quote)
+		} else if msgType == 0 {
+			trade := parseOptionTrade(data[startIndex:(startIndex+OPTION_TRADE_MSG_SIZE)], symbols)
+			startIndex = startIndex + OPTION_TRADE_MSG_SIZE
+			countEvent(tradeEvent)
+			dispatcher.DispatchTrade(trade)
+		} else if msgType > 2 {
+			ua := parseOptionUA(data[startIndex:(startIndex+OPTION_UA_MSG_SIZE)], symbols)
+			startIndex = startIndex + OPTION_UA_MSG_SIZE
+			countEvent(unusualActivityEvent)
+			dispatcher.DispatchUnusualActivity(ua)
+		} else if msgType == 2 {
+			refresh := parseOptionRefresh(data[startIndex:(startIndex+OPTION_REFRESH_MSG_SIZE)], symbols)
+			startIndex = startIndex + OPTION_REFRESH_MSG_SIZE
+			countEvent(refreshEvent)
+			dispatcher.DispatchRefresh(refresh)
+		}
+	}
+	if startIndex < len(data) {
+		return fmt.Errorf("%w: %d trailing bytes after %d messages", ErrInvalidFrame, len(data)-startIndex, count)
+	}
+	return nil
+}
+
+func workOnOptions(
+	data []byte,
+	releaseFrame func([]byte),
+	logger Logger,
+	dispatcher OptionDispatcher,
+	countEvent func(eventKind),
+	symbols *symbolTable) {
+	if decodeErr := decodeOptionFrame(data, dispatcher, countEvent, symbols); decodeErr != nil {
+		logger.Error("Option Client - %v\n", decodeErr)
 	}
+	releaseFrame(data)
 }
 
 func composeOptionJoinMsg(
@@ -441,7 +645,6 @@ func composeOptionJoinMsg(
 	message := make([]byte, 0, len(newSymbol)+2)
 	message = append(message, 74, mask)
 	message = append(message, []byte(newSymbol)...)
-	log.Printf("Option Client - Composed join msg for channel %s\n", newSymbol)
 	return message
 }
 
@@ -450,6 +653,5 @@ func composeOptionLeaveMsg(symbol string) []byte {
 	message := make([]byte, 0, len(newSymbol)+2)
 	message = append(message, 76, 0)
 	message = append(message, []byte(newSymbol)...)
-	log.Printf("Option Client - Composed leave msg for channel %s\n", newSymbol)
 	return message
 }
@@ -0,0 +1,100 @@
+package intrinio
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func composeTestOptionTrade(contract string) []byte {
+	msg := make([]byte, OPTION_TRADE_MSG_SIZE)
+	msg[0] = byte(len(contract))
+	copy(msg[1:(1+MAX_OPTION_SYMBOL_SIZE)], contract)
+	msg[22] = 0
+	msg[23] = 2
+	msg[24] = 2
+	binary.LittleEndian.PutUint32(msg[25:29], 15025)
+	binary.LittleEndian.PutUint32(msg[29:33], 10)
+	binary.LittleEndian.PutUint64(msg[33:41], 1700000000000000000)
+	binary.LittleEndian.PutUint64(msg[41:49], 500)
+	msg[65] = byte(CBOE)
+	return msg
+}
+
+func composeTestOptionQuote(contract string) []byte {
+	msg := make([]byte, OPTION_QUOTE_MSG_SIZE)
+	msg[0] = byte(len(contract))
+	copy(msg[1:(1+MAX_OPTION_SYMBOL_SIZE)], contract)
+	msg[22] = 1
+	msg[23] = 2
+	binary.LittleEndian.PutUint32(msg[24:28], 15050)
+	binary.LittleEndian.PutUint32(msg[28:32], 5)
+	binary.LittleEndian.PutUint32(msg[32:36], 15000)
+	binary.LittleEndian.PutUint32(msg[36:40], 7)
+	binary.LittleEndian.PutUint64(msg[40:48], 1700000000000000000)
+	return msg
+}
+
+func TestDecodeOptionFrame(t *testing.T) {
+	frame := append([]byte{2}, composeTestOptionTrade("AAPL_240119C150.00")...)
+	frame = append(frame, composeTestOptionQuote("SPY_240119P45.50")...)
+	var trades []OptionTrade
+	var quotes []OptionQuote
+	dispatcher := NewOptionDispatcher(
+		func(trade OptionTrade) { trades = append(trades, trade) },
+		func(quote OptionQuote) { quotes = append(quotes, quote) },
+		nil,
+		nil)
+	if decodeErr := DecodeOptionFrame(frame, dispatcher); decodeErr != nil {
+		t.Fatalf("unexpected error: %v", decodeErr)
+	}
+	if (len(trades) != 1) || (trades[0].ContractId != "AAPL__240119C00150000") || (trades[0].Price != 150.25) || (trades[0].Size != 10) || (trades[0].Exchange != CBOE) {
+		t.Fatalf("unexpected trades: %+v", trades)
+	}
+	if (len(quotes) != 1) || (quotes[0].ContractId != "SPY___240119P00045500") || (quotes[0].AskPrice != 150.5) || (quotes[0].BidSize != 7) {
+		t.Fatalf("unexpected quotes: %+v", quotes)
+	}
+}
+
+func TestDecodeOptionFrameRejectsMalformedFrames(t *testing.T) {
+	trade := composeTestOptionTrade("AAPL_240119C150.00")
+	emptyContract := append([]byte{}, trade...)
+	emptyContract[0] = 0
+	longContract := append([]byte{}, trade...)
+	longContract[0] = byte(MAX_OPTION_SYMBOL_SIZE + 1)
+	frames := map[string][]byte{
+		"empty":              {},
+		"truncated header":   {1, 4, 'A'},
+		"short trade":        append([]byte{1}, trade[:(OPTION_TRADE_MSG_SIZE-1)]...),
+		"empty contract":     append([]byte{1}, emptyContract...),
+		"contract overrun":   append([]byte{1}, longContract...),
+		"missing underscore": append([]byte{1}, composeTestOptionTrade("AAPL240119C150.00")...),
+		"missing decimal":    append([]byte{1}, composeTestOptionTrade("AAPL_240119C15000")...),
+		"short contract":     append([]byte{1}, composeTestOptionTrade("AAPL_2401.0")...),
+		"long underlying":    append([]byte{1}, composeTestOptionTrade("ABCDEFG_240119C1.00")...),
+		"long strike":        append([]byte{1}, composeTestOptionTrade("A_240119C1234567.00")...),
+		"long fraction":      append([]byte{1}, composeTestOptionTrade("A_240119C1.123456")...),
+		"missing message":    append([]byte{2}, trade...),
+		"trailing bytes":     append(append([]byte{1}, trade...), 0),
+	}
+	dispatcher := NewOptionDispatcher(func(OptionTrade) {}, func(OptionQuote) {}, func(OptionRefresh) {}, func(OptionUnusualActivity) {})
+	for name, frame := range frames {
+		if decodeErr := DecodeOptionFrame(frame, dispatcher); !errors.Is(decodeErr, ErrInvalidFrame) {
+			t.Errorf("%s: expected ErrInvalidFrame, got %v", name, decodeErr)
+		}
+	}
+}
+
+func TestDecodeOptionFrameInvalidPriceType(t *testing.T) {
+	trade := composeTestOptionTrade("AAPL_240119C150.00")
+	trade[23] = 200
+	var price float32
+	dispatcher := NewOptionDispatcher(func(trade OptionTrade) { price = trade.Price }, nil, nil, nil)
+	if decodeErr := DecodeOptionFrame(append([]byte{1}, trade...), dispatcher); decodeErr != nil {
+		t.Fatalf("unexpected error: %v", decodeErr)
+	}
+	if !math.IsNaN(float64(price)) {
+		t.Fatalf("expected NaN price, got %v", price)
+	}
+}
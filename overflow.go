@@ -0,0 +1,222 @@
+package intrinio
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const MIN_OVERFLOW_CAPACITY int = 64
+
+type overflowQueue struct {
+	lock        sync.Mutex
+	frames      [][]byte
+	head        int
+	count       int
+	bytes       int64
+	maxBytes    int64
+	growCount   uint64
+	shrinkCount uint64
+	dropCount   uint64
+	isHolding   bool
+	notify      chan bool
+	isDraining  uint32
+}
+
+func newOverflowQueue(maxBytes int64) *overflowQueue {
+	return &overflowQueue{
+		frames:   make([][]byte, MIN_OVERFLOW_CAPACITY),
+		maxBytes: maxBytes,
+		notify:   make(chan bool, 1),
+	}
+}
+
+func (queue *overflowQueue) resize(capacity int) {
+	frames := make([][]byte, capacity)
+	for i := 0; i < queue.count; i++ {
+		frames[i] = queue.frames[(queue.head+i)%len(queue.frames)]
+	}
+	queue.frames = frames
+	queue.head = 0
+}
+
+func (queue *overflowQueue) push(data []byte) bool {
+	queue.lock.Lock()
+	if queue.bytes+int64(cap(data)) > queue.maxBytes {
+		queue.lock.Unlock()
+		return false
+	}
+	if queue.count == len(queue.frames) {
+		queue.resize(len(queue.frames) * 2)
+		queue.growCount++
+	}
+	queue.frames[(queue.head+queue.count)%len(queue.frames)] = data
+	queue.count++
+	queue.bytes += int64(cap(data))
+	queue.lock.Unlock()
+	select {
+	case queue.notify <- true:
+	default:
+	}
+	return true
+}
+
+func (queue *overflowQueue) take() ([]byte, bool) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if queue.count == 0 {
+		return nil, false
+	}
+	queue.isHolding = true
+	return queue.pop(), true
+}
+
+func (queue *overflowQueue) release() {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	queue.isHolding = false
+}
+
+func (queue *overflowQueue) evict() ([]byte, bool) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if queue.count == 0 {
+		return nil, false
+	}
+	return queue.pop(), true
+}
+
+func (queue *overflowQueue) pop() []byte {
+	data := queue.frames[queue.head]
+	queue.frames[queue.head] = nil
+	queue.head = (queue.head + 1) % len(queue.frames)
+	queue.count--
+	queue.bytes -= int64(cap(data))
+	if (len(queue.frames) > MIN_OVERFLOW_CAPACITY) && (queue.count <= len(queue.frames)/4) {
+		queue.resize(len(queue.frames) / 2)
+		queue.shrinkCount++
+	}
+	return data
+}
+
+func (queue *overflowQueue) isEmpty() bool {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	return (queue.count == 0) && !queue.isHolding
+}
+
+func (queue *overflowQueue) countDrop() {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	queue.dropCount++
+}
+
+func (queue *overflowQueue) getStats() (int, int64, uint64, uint64, uint64) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	return queue.count, queue.bytes, queue.growCount, queue.shrinkCount, queue.dropCount
+}
+
+func (client *Client) dropFrame(data []byte) {
+	atomic.AddUint64(&client.droppedFrameCount, 1)
+	if len(data) > 0 {
+		atomic.AddUint64(&client.droppedEventCount, uint64(data[0]))
+	}
+	if client.overflow != nil {
+		client.overflow.countDrop()
+	}
+	client.releaseFrame(data)
+}
+
+func (client *Client) enqueueFrame(data []byte) bool {
+	if (client.overflow == nil) || client.overflow.isEmpty() {
+		select {
+		case client.readChannel <- data:
+			return true
+		default:
+		}
+	}
+	if client.overflow == nil {
+		switch client.overflowPolicy.Load().(OverflowPolicy) {
+		case OVERFLOW_BLOCK:
+			client.readChannel <- data
+			return false
+		case OVERFLOW_DROP_OLDEST:
+			for {
+				select {
+				case oldest := <-client.readChannel:
+					client.dropFrame(oldest)
+				default:
+				}
+				select {
+				case client.readChannel <- data:
+					return false
+				default:
+				}
+			}
+		}
+		client.dropFrame(data)
+		return false
+	}
+	if client.overflow.push(data) {
+		return true
+	}
+	switch client.overflowPolicy.Load().(OverflowPolicy) {
+	case OVERFLOW_BLOCK:
+		for !client.isStopped {
+			time.Sleep(10 * time.Millisecond)
+			if client.overflow.push(data) {
+				return false
+			}
+		}
+	case OVERFLOW_DROP_OLDEST:
+		for oldest, ok := client.overflow.evict(); ok; oldest, ok = client.overflow.evict() {
+			client.dropFrame(oldest)
+			if client.overflow.push(data) {
+				return false
+			}
+		}
+	}
+	client.dropFrame(data)
+	return false
+}
+
+func (client *Client) drainOverflow() {
+	if !atomic.CompareAndSwapUint32(&client.overflow.isDraining, 0, 1) {
+		return
+	}
+	defer atomic.StoreUint32(&client.overflow.isDraining, 0)
+	for !client.isStopped {
+		data, ok := client.overflow.take()
+		if !ok {
+			select {
+			case <-client.overflow.notify:
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for !client.sendOverflowFrame(data) {
+			if client.isStopped {
+				client.releaseFrame(data)
+				break
+			}
+		}
+		client.overflow.release()
+	}
+}
+
+func (client *Client) sendOverflowFrame(data []byte) bool {
+	select {
+	case client.readChannel <- data:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func (client *Client) addOverflowStats(report *StatsReport) {
+	if client.overflow == nil {
+		return
+	}
+	report.OverflowDepth, report.OverflowBytes, report.OverflowGrowCount, report.OverflowShrinkCount, report.OverflowDropCount = client.overflow.getStats()
+}
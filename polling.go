@@ -0,0 +1,269 @@
+package intrinio
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrPollingProvider = errors.New("Polling Client - Polling supports the IEX, DELAYED_SIP, NASDAQ_BASIC, and CBOE_ONE providers")
+
+type PollingConfig struct {
+	ApiKey        string
+	Provider      Provider
+	Symbols       []string
+	Interval      time.Duration
+	AppIdentifier string
+	Headers       map[string]string
+	HTTPClient    *http.Client
+	RestPolicy    RestPolicy
+}
+
+var DefaultPollingInterval time.Duration = 5 * time.Second
+
+type restEquityPrice struct {
+	LastPrice        float64 `json:"last_price"`
+	LastTime         string  `json:"last_time"`
+	LastSize         float64 `json:"last_size"`
+	BidPrice         float64 `json:"bid_price"`
+	BidSize          float64 `json:"bid_size"`
+	AskPrice         float64 `json:"ask_price"`
+	AskSize          float64 `json:"ask_size"`
+	MarketVolume     float64 `json:"market_volume"`
+	UpdatedOn        string  `json:"updated_on"`
+	SalesConditions  string  `json:"sales_conditions"`
+	QuoteConditions  string  `json:"quote_conditions"`
+	MarketCenterCode string  `json:"market_center_code"`
+}
+
+type polledEquityState struct {
+	trade EquityTrade
+	ask   EquityQuote
+	bid   EquityQuote
+}
+
+type PollingClient struct {
+	config  PollingConfig
+	source  string
+	rest    *restClient
+	logger  Logger
+	onTrade func(EquityTrade)
+	onQuote func(EquityQuote)
+	lock    sync.Mutex
+	symbols map[string]bool
+	states  map[string]polledEquityState
+	done    chan bool
+}
+
+func getPollingSource(provider Provider) (string, error) {
+	switch provider {
+	case IEX, DELAYED_SIP, NASDAQ_BASIC, CBOE_ONE:
+		return strings.ToLower(string(provider)), nil
+	}
+	return "", ErrPollingProvider
+}
+
+func parseRestTime(value string) (float64, uint64) {
+	t, parseErr := time.Parse(time.RFC3339Nano, value)
+	if parseErr != nil {
+		return 0.0, 0
+	}
+	timestampNs := uint64(t.UnixNano())
+	return float64(timestampNs) / 1000000000.0, timestampNs
+}
+
+func NewEquitiesPollingClient(
+	c PollingConfig,
+	onTrade func(EquityTrade),
+	onQuote func(EquityQuote)) *PollingClient {
+	if c.Interval <= 0 {
+		c.Interval = DefaultPollingInterval
+	}
+	pollingClient := &PollingClient{
+		config:  c,
+		rest:    newRestClient(getHTTPClient(c.HTTPClient), c.ApiKey, getRequestHeader(c.AppIdentifier, c.Headers), c.RestPolicy),
+		logger:  defaultLogger,
+		onTrade: onTrade,
+		onQuote: onQuote,
+		symbols: make(map[string]bool),
+		states:  make(map[string]polledEquityState),
+	}
+	pollingClient.JoinMany(c.Symbols)
+	return pollingClient
+}
+
+func (pollingClient *PollingClient) SetLogger(logger Logger) {
+	pollingClient.logger = logger
+}
+
+func (pollingClient *PollingClient) GetRestStats() RestStats {
+	return pollingClient.rest.getStats()
+}
+
+func (pollingClient *PollingClient) Start() error {
+	if strings.TrimSpace(pollingClient.config.ApiKey) == "" {
+		return ErrMissingApiKey
+	}
+	source, sourceErr := getPollingSource(pollingClient.config.Provider)
+	if sourceErr != nil {
+		return sourceErr
+	}
+	pollingClient.lock.Lock()
+	if pollingClient.done != nil {
+		pollingClient.lock.Unlock()
+		return nil
+	}
+	pollingClient.source = source
+	done := make(chan bool)
+	pollingClient.done = done
+	pollingClient.lock.Unlock()
+	pollingClient.logger.Info("Polling Client - Polling every %v\n", pollingClient.config.Interval)
+	go func() {
+		ticker := time.NewTicker(pollingClient.config.Interval)
+		defer ticker.Stop()
+		pollingClient.poll()
+		for {
+			select {
+			case <-done:
+				done <- true
+				return
+			case <-ticker.C:
+				pollingClient.poll()
+			}
+		}
+	}()
+	return nil
+}
+
+func (pollingClient *PollingClient) Stop() {
+	pollingClient.lock.Lock()
+	done := pollingClient.done
+	pollingClient.done = nil
+	pollingClient.lock.Unlock()
+	if done != nil {
+		done <- true
+		<-done
+		pollingClient.logger.Info("Polling Client - Stopped")
+	}
+}
+
+func (pollingClient *PollingClient) Join(symbol string) {
+	if s := strings.TrimSpace(symbol); s != "" {
+		pollingClient.lock.Lock()
+		pollingClient.symbols[s] = true
+		pollingClient.lock.Unlock()
+	}
+}
+
+func (pollingClient *PollingClient) JoinMany(symbols []string) {
+	for i := 0; i < len(symbols); i++ {
+		pollingClient.Join(symbols[i])
+	}
+}
+
+func (pollingClient *PollingClient) Leave(symbol string) {
+	pollingClient.lock.Lock()
+	delete(pollingClient.symbols, symbol)
+	delete(pollingClient.states, symbol)
+	pollingClient.lock.Unlock()
+}
+
+func (pollingClient *PollingClient) LeaveMany(symbols []string) {
+	for i := 0; i < len(symbols); i++ {
+		pollingClient.Leave(symbols[i])
+	}
+}
+
+func (pollingClient *PollingClient) LeaveAll() {
+	pollingClient.lock.Lock()
+	pollingClient.symbols = make(map[string]bool)
+	pollingClient.states = make(map[string]polledEquityState)
+	pollingClient.lock.Unlock()
+}
+
+func (pollingClient *PollingClient) GetSubscriptions() []string {
+	pollingClient.lock.Lock()
+	defer pollingClient.lock.Unlock()
+	symbols := make([]string, 0, len(pollingClient.symbols))
+	for symbol := range pollingClient.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (pollingClient *PollingClient) poll() {
+	for _, symbol := range pollingClient.GetSubscriptions() {
+		price, fetchErr := pollingClient.fetch(symbol)
+		if fetchErr != nil {
+			pollingClient.logger.Warn("Polling Client - Failure to poll %s: %v\n", symbol, fetchErr)
+			continue
+		}
+		pollingClient.publish(symbol, price)
+	}
+}
+
+func (pollingClient *PollingClient) fetch(symbol string) (restEquityPrice, error) {
+	var price restEquityPrice
+	fetchErr := pollingClient.rest.getJSON(
+		"/securities/"+url.PathEscape(symbol)+"/prices/realtime",
+		url.Values{"source": {pollingClient.source}},
+		&price)
+	return price, fetchErr
+}
+
+func (pollingClient *PollingClient) publish(symbol string, price restEquityPrice) {
+	var marketCenter rune = 0
+	if len(price.MarketCenterCode) > 0 {
+		marketCenter = rune(price.MarketCenterCode[0])
+	}
+	tradeTimestamp, tradeTimestampNs := parseRestTime(price.LastTime)
+	quoteTimestamp, quoteTimestampNs := parseRestTime(price.UpdatedOn)
+	trade := EquityTrade{
+		Symbol:       symbol,
+		MarketCenter: marketCenter,
+		Price:        float32(price.LastPrice),
+		Size:         uint32(price.LastSize),
+		TotalVolume:  uint32(price.MarketVolume),
+		Timestamp:    tradeTimestamp,
+		Conditions:   price.SalesConditions,
+		TimestampNs:  tradeTimestampNs,
+	}
+	ask := EquityQuote{
+		Type:         ASK,
+		Symbol:       symbol,
+		MarketCenter: marketCenter,
+		Price:        float32(price.AskPrice),
+		Size:         uint32(price.AskSize),
+		Timestamp:    quoteTimestamp,
+		Conditions:   price.QuoteConditions,
+		TimestampNs:  quoteTimestampNs,
+	}
+	bid := ask
+	bid.Type = BID
+	bid.Price = float32(price.BidPrice)
+	bid.Size = uint32(price.BidSize)
+	pollingClient.lock.Lock()
+	if !pollingClient.symbols[symbol] {
+		pollingClient.lock.Unlock()
+		return
+	}
+	previous := pollingClient.states[symbol]
+	pollingClient.states[symbol] = polledEquityState{trade: trade, ask: ask, bid: bid}
+	pollingClient.lock.Unlock()
+	if (pollingClient.onTrade != nil) && (trade.Price > 0) && (trade.TimestampNs != previous.trade.TimestampNs) {
+		pollingClient.onTrade(trade)
+	}
+	if pollingClient.onQuote != nil {
+		if (ask.Price > 0) && ((ask.Price != previous.ask.Price) || (ask.Size != previous.ask.Size)) {
+			pollingClient.onQuote(ask)
+		}
+		if (bid.Price > 0) && ((bid.Price != previous.bid.Price) || (bid.Size != previous.bid.Size)) {
+			pollingClient.onQuote(bid)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package intrinio
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const QOS_FLUSH_INTERVAL time.Duration = 10 * time.Millisecond
+
+type GroupQoS struct {
+	QuoteConflation time.Duration
+}
+
+type GroupQoSStats struct {
+	Group           string
+	QuoteConflation time.Duration
+	TradeCount      uint64
+	QuoteCount      uint64
+	DeliveredCount  uint64
+	ConflatedCount  uint64
+}
+
+type qosKey struct {
+	symbol string
+	side   QuoteType
+}
+
+type qosGroup struct {
+	name           string
+	qos            GroupQoS
+	lastFlush      time.Time
+	pending        map[qosKey]func()
+	tradeCount     uint64
+	quoteCount     uint64
+	deliveredCount uint64
+	conflatedCount uint64
+}
+
+type qosController struct {
+	lock    sync.Mutex
+	groups  map[string]*qosGroup
+	routes  atomic.Value
+	running uint32
+	done    chan bool
+}
+
+func newQoSController() *qosController {
+	controller := &qosController{
+		groups: make(map[string]*qosGroup),
+		done:   make(chan bool),
+	}
+	controller.routes.Store(map[string]*qosGroup{})
+	return controller
+}
+
+func (controller *qosController) set(name string, qos GroupQoS) []func() {
+	controller.lock.Lock()
+	defer controller.lock.Unlock()
+	group, ok := controller.groups[name]
+	if qos.QuoteConflation <= 0 {
+		if !ok {
+			return nil
+		}
+		delete(controller.groups, name)
+		return group.drain()
+	}
+	if !ok {
+		group = &qosGroup{
+			name:      name,
+			lastFlush: time.Now(),
+			pending:   make(map[qosKey]func()),
+		}
+		controller.groups[name] = group
+	}
+	group.qos = qos
+	return nil
+}
+
+func (controller *qosController) route(groups map[string]map[string]bool) {
+	controller.lock.Lock()
+	defer controller.lock.Unlock()
+	routes := make(map[string]*qosGroup)
+	for name, group := range controller.groups {
+		for symbol := range groups[name] {
+			if current, ok := routes[symbol]; !ok || (group.qos.QuoteConflation < current.qos.QuoteConflation) {
+				routes[symbol] = group
+			}
+		}
+	}
+	controller.routes.Store(routes)
+}
+
+func (controller *qosController) getRoutes() map[string]*qosGroup {
+	return controller.routes.Load().(map[string]*qosGroup)
+}
+
+func (controller *qosController) push(group *qosGroup, key qosKey, deliver func()) {
+	controller.lock.Lock()
+	group.quoteCount++
+	if _, ok := group.pending[key]; ok {
+		group.conflatedCount++
+	}
+	group.pending[key] = deliver
+	controller.lock.Unlock()
+}
+
+func (group *qosGroup) drain() []func() {
+	ready := make([]func(), 0, len(group.pending))
+	for key, deliver := range group.pending {
+		ready = append(ready, deliver)
+		delete(group.pending, key)
+	}
+	group.deliveredCount += uint64(len(ready))
+	return ready
+}
+
+func (controller *qosController) collect(now time.Time, all bool) []func() {
+	controller.lock.Lock()
+	defer controller.lock.Unlock()
+	ready := make([]func(), 0)
+	for _, group := range controller.groups {
+		if all || (now.Sub(group.lastFlush) >= group.qos.QuoteConflation) {
+			group.lastFlush = now
+			ready = append(ready, group.drain()...)
+		}
+	}
+	return ready
+}
+
+func (controller *qosController) start() {
+	if atomic.CompareAndSwapUint32(&controller.running, 0, 1) {
+		go controller.run()
+	}
+}
+
+func (controller *qosController) run() {
+	ticker := time.NewTicker(QOS_FLUSH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-controller.done:
+			for _, deliver := range controller.collect(time.Now(), true) {
+				deliver()
+			}
+			controller.done <- true
+			return
+		case now := <-ticker.C:
+			for _, deliver := range controller.collect(now, false) {
+				deliver()
+			}
+		}
+	}
+}
+
+func (controller *qosController) stop() {
+	if atomic.CompareAndSwapUint32(&controller.running, 1, 0) {
+		controller.done <- true
+		<-controller.done
+	}
+}
+
+func (controller *qosController) getStats() []GroupQoSStats {
+	controller.lock.Lock()
+	defer controller.lock.Unlock()
+	stats := make([]GroupQoSStats, 0, len(controller.groups))
+	for _, name := range sortedKeys(controller.groups) {
+		group := controller.groups[name]
+		stats = append(stats, GroupQoSStats{
+			Group:           name,
+			QuoteConflation: group.qos.QuoteConflation,
+			TradeCount:      atomic.LoadUint64(&group.tradeCount),
+			QuoteCount:      group.quoteCount,
+			DeliveredCount:  group.deliveredCount,
+			ConflatedCount:  group.conflatedCount,
+		})
+	}
+	return stats
+}
+
+func (handlers equityHandlers) conflated(controller *qosController, routes map[string]*qosGroup) equityHandlers {
+	result := equityHandlers{}
+	if onTrade := handlers.onTrade; onTrade != nil {
+		result.onTrade = func(trade EquityTrade) {
+			if group, ok := routes[trade.Symbol]; ok {
+				atomic.AddUint64(&group.tradeCount, 1)
+			}
+			onTrade(trade)
+		}
+	}
+	if onQuote := handlers.onQuote; onQuote != nil {
+		result.onQuote = func(quote EquityQuote) {
+			if group, ok := routes[quote.Symbol]; ok {
+				controller.push(group, qosKey{symbol: quote.Symbol, side: quote.Type}, func() { onQuote(quote) })
+			} else {
+				onQuote(quote)
+			}
+		}
+	}
+	return result
+}
+
+func (handlers optionHandlers) conflated(controller *qosController, routes map[string]*qosGroup) optionHandlers {
+	result := handlers
+	if onTrade := handlers.onTrade; onTrade != nil {
+		result.onTrade = func(trade OptionTrade) {
+			if group, ok := routes[trade.ContractId]; ok {
+				atomic.AddUint64(&group.tradeCount, 1)
+			}
+			onTrade(trade)
+		}
+	}
+	if onQuote := handlers.onQuote; onQuote != nil {
+		result.onQuote = func(quote OptionQuote) {
+			if group, ok := routes[quote.ContractId]; ok {
+				controller.push(group, qosKey{symbol: quote.ContractId}, func() { onQuote(quote) })
+			} else {
+				onQuote(quote)
+			}
+		}
+	}
+	return result
+}
+
+func (client *Client) SetGroupQoS(name string, qos GroupQoS) {
+	client.subscriptionsLock.Lock()
+	ready := client.qos.set(name, qos)
+	client.qos.route(client.groups)
+	client.subscriptionsLock.Unlock()
+	for _, deliver := range ready {
+		deliver()
+	}
+	client.logger.Info("Client - Set quote conflation of group %s to %v\n", name, qos.QuoteConflation)
+}
+
+func (client *Client) GetGroupQoSStats() []GroupQoSStats {
+	return client.qos.getStats()
+}
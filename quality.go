@@ -0,0 +1,100 @@
+package intrinio
+
+import (
+	"sync/atomic"
+)
+
+type TradeThroughSide uint8
+
+const (
+	ABOVE_ASK TradeThroughSide = 1
+	BELOW_BID TradeThroughSide = 2
+)
+
+func (side TradeThroughSide) String() string {
+	switch side {
+	case ABOVE_ASK:
+		return "ABOVE_ASK"
+	case BELOW_BID:
+		return "BELOW_BID"
+	}
+	return "unknown"
+}
+
+type OptionTradeThrough struct {
+	Trade     OptionTrade
+	Side      TradeThroughSide
+	Deviation float32
+}
+
+type TradeThroughCounts struct {
+	Checked     uint64
+	AboveAsk    uint64
+	BelowBid    uint64
+	MissingNBBO uint64
+}
+
+func (trade OptionTrade) HasValidNBBO() bool {
+	return (trade.AskPriceAtExecution > 0) &&
+		(trade.BidPriceAtExecution > 0) &&
+		(trade.AskPriceAtExecution >= trade.BidPriceAtExecution)
+}
+
+func (trade OptionTrade) IsOutsideNBBO(tolerance float32) bool {
+	return trade.HasValidNBBO() &&
+		((trade.Price > trade.AskPriceAtExecution+tolerance) ||
+			(trade.Price < trade.BidPriceAtExecution-tolerance))
+}
+
+type TradeThroughValidator struct {
+	tolerance      float32
+	onTradeThrough func(OptionTradeThrough)
+	checked        uint64
+	aboveAsk       uint64
+	belowBid       uint64
+	missingNBBO    uint64
+}
+
+func NewTradeThroughValidator(tolerance float32, onTradeThrough func(OptionTradeThrough)) *TradeThroughValidator {
+	return &TradeThroughValidator{
+		tolerance:      tolerance,
+		onTradeThrough: onTradeThrough,
+	}
+}
+
+func (validator *TradeThroughValidator) OnOptionTrade(trade OptionTrade) {
+	atomic.AddUint64(&validator.checked, 1)
+	if !trade.HasValidNBBO() {
+		atomic.AddUint64(&validator.missingNBBO, 1)
+		return
+	}
+	var event OptionTradeThrough
+	if trade.Price > trade.AskPriceAtExecution+validator.tolerance {
+		atomic.AddUint64(&validator.aboveAsk, 1)
+		event = OptionTradeThrough{Trade: trade, Side: ABOVE_ASK, Deviation: trade.Price - trade.AskPriceAtExecution}
+	} else if trade.Price < trade.BidPriceAtExecution-validator.tolerance {
+		atomic.AddUint64(&validator.belowBid, 1)
+		event = OptionTradeThrough{Trade: trade, Side: BELOW_BID, Deviation: trade.BidPriceAtExecution - trade.Price}
+	} else {
+		return
+	}
+	if validator.onTradeThrough != nil {
+		validator.onTradeThrough(event)
+	}
+}
+
+func (validator *TradeThroughValidator) GetCounts() TradeThroughCounts {
+	return TradeThroughCounts{
+		Checked:     atomic.LoadUint64(&validator.checked),
+		AboveAsk:    atomic.LoadUint64(&validator.aboveAsk),
+		BelowBid:    atomic.LoadUint64(&validator.belowBid),
+		MissingNBBO: atomic.LoadUint64(&validator.missingNBBO),
+	}
+}
+
+func (validator *TradeThroughValidator) ResetSession() {
+	atomic.StoreUint64(&validator.checked, 0)
+	atomic.StoreUint64(&validator.aboveAsk, 0)
+	atomic.StoreUint64(&validator.belowBid, 0)
+	atomic.StoreUint64(&validator.missingNBBO, 0)
+}
@@ -0,0 +1,67 @@
+package intrinio
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type ReferenceData struct {
+	Ticker           string
+	Name             string
+	PrimaryExchange  string
+	Sector           string
+	IndustryCategory string
+	MarketCap        float64
+}
+
+type restCompany struct {
+	Ticker           string `json:"ticker"`
+	Name             string `json:"name"`
+	StockExchange    string `json:"stock_exchange"`
+	Sector           string `json:"sector"`
+	IndustryCategory string `json:"industry_category"`
+}
+
+type referenceCache struct {
+	lock    sync.Mutex
+	tickers map[string]ReferenceData
+}
+
+func (client *Client) GetReferenceData(ticker string) (ReferenceData, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	client.references.lock.Lock()
+	data, ok := client.references.tickers[ticker]
+	client.references.lock.Unlock()
+	if ok {
+		return data, nil
+	}
+	var company restCompany
+	if fetchErr := client.rest.getJSON("/companies/"+url.PathEscape(ticker), nil, &company); fetchErr != nil {
+		client.logger.Warn("Client - Failure to load reference data for %s: %v\n", ticker, fetchErr)
+		return ReferenceData{}, fetchErr
+	}
+	var marketCap float64
+	if fetchErr := client.rest.getJSON("/companies/"+url.PathEscape(ticker)+"/data_point/marketcap/number", nil, &marketCap); fetchErr != nil {
+		client.logger.Warn("Client - Failure to load market cap for %s: %v\n", ticker, fetchErr)
+		return ReferenceData{}, fetchErr
+	}
+	data = ReferenceData{
+		Ticker:           ticker,
+		Name:             company.Name,
+		PrimaryExchange:  company.StockExchange,
+		Sector:           company.Sector,
+		IndustryCategory: company.IndustryCategory,
+		MarketCap:        marketCap,
+	}
+	client.references.lock.Lock()
+	client.references.tickers[ticker] = data
+	client.references.lock.Unlock()
+	return data, nil
+}
+
+func (client *Client) ClearReferenceData() {
+	client.references.lock.Lock()
+	client.references.tickers = make(map[string]ReferenceData)
+	client.references.lock.Unlock()
+}
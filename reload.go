@@ -0,0 +1,90 @@
+package intrinio
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+const CONFIG_GROUP string = "$CONFIG"
+
+func (client *Client) Reload(config Config) error {
+	client.reloadLock.Lock()
+	defer client.reloadLock.Unlock()
+	if validateErr := config.validate(client.isOptionsClient()); validateErr != nil {
+		return validateErr
+	}
+	current := client.getConfig()
+	if (config.ApiKey != current.ApiKey) ||
+		(config.Provider != current.Provider) ||
+		(config.IPAddress != current.IPAddress) ||
+		(config.ReorderMaxDelayMs != current.ReorderMaxDelayMs) ||
+		(config.MaxOverflowBytes != current.MaxOverflowBytes) ||
+		(config.NumWorkers != current.NumWorkers) ||
+		(config.ReadQueueDepth != current.ReadQueueDepth) ||
+		(config.WriteQueueDepth != current.WriteQueueDepth) ||
+		(config.ReadBufferSize != current.ReadBufferSize) ||
+		(config.WriteBufferSize != current.WriteBufferSize) {
+		return ErrReloadRestart
+	}
+	switch client.filters.Load().(type) {
+	case optionFilters:
+		filters, filterErr := config.getOptionFilters()
+		if filterErr != nil {
+			return filterErr
+		}
+		client.filters.Store(filters)
+	case equityFilters:
+		filters, filterErr := config.getEquityFilters()
+		if filterErr != nil {
+			return filterErr
+		}
+		client.filters.Store(filters)
+	}
+	if level, ok := config.getLogLevel(); ok {
+		if stdLogger, isStdLogger := client.logger.get().(*StdLogger); isStdLogger {
+			stdLogger.SetLevel(level)
+		}
+	}
+	overflowPolicy, _ := config.getOverflowPolicy()
+	client.overflowPolicy.Store(overflowPolicy)
+	client.setKeepAlive(config)
+	client.setHeartbeat(config)
+	client.rest.configure(getRequestHeader(config.AppIdentifier, config.Headers), config.RestPolicy)
+	guard, _ := config.getSubscriptionGuard()
+	client.subscriptionGuard.Store(guard)
+	atomic.StoreInt64(&client.statsInterval, int64(config.getStatsReportInterval()))
+	if !client.isStopped {
+		client.SwapGroup(CONFIG_GROUP, config.Symbols)
+	}
+	client.config.Store(&config)
+	client.logger.Info("Client - Configuration reloaded")
+	return nil
+}
+
+func (client *Client) ReloadOnSIGHUP(filename string) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				config, loadErr := LoadConfigFromFile(filename)
+				if loadErr == nil {
+					loadErr = client.Reload(config)
+				}
+				if loadErr != nil {
+					client.logger.Error("Client - Failure to reload configuration: %v\n", loadErr)
+				}
+			case <-done:
+				signal.Stop(signals)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
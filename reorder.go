@@ -0,0 +1,190 @@
+package intrinio
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const REORDER_IDLE_TIMEOUT time.Duration = time.Minute
+
+type reorderEvent struct {
+	timestampNs uint64
+	received    time.Time
+	sequence    uint64
+	deliver     func()
+}
+
+type reorderHeap []reorderEvent
+
+func (h reorderHeap) Len() int {
+	return len(h)
+}
+
+func (h reorderHeap) Less(i, j int) bool {
+	if h[i].timestampNs == h[j].timestampNs {
+		return h[i].sequence < h[j].sequence
+	}
+	return h[i].timestampNs < h[j].timestampNs
+}
+
+func (h reorderHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *reorderHeap) Push(x any) {
+	*h = append(*h, x.(reorderEvent))
+}
+
+func (h *reorderHeap) Pop() any {
+	old := *h
+	n := len(old)
+	event := old[n-1]
+	*h = old[0 : n-1]
+	return event
+}
+
+type reorderSymbol struct {
+	pending      reorderHeap
+	lastReleased uint64
+	lastActive   time.Time
+}
+
+type reorderBuffer struct {
+	maxDelay  time.Duration
+	lock      sync.Mutex
+	symbols   map[string]*reorderSymbol
+	sequence  uint64
+	lateCount uint64
+	running   uint32
+	done      chan bool
+}
+
+func newReorderBuffer(maxDelay time.Duration) *reorderBuffer {
+	return &reorderBuffer{
+		maxDelay: maxDelay,
+		symbols:  make(map[string]*reorderSymbol),
+		done:     make(chan bool),
+	}
+}
+
+func (buffer *reorderBuffer) push(symbol string, timestampNs uint64, deliver func()) {
+	now := time.Now()
+	buffer.lock.Lock()
+	state, ok := buffer.symbols[symbol]
+	if !ok {
+		state = &reorderSymbol{}
+		buffer.symbols[symbol] = state
+	}
+	state.lastActive = now
+	if timestampNs < state.lastReleased {
+		buffer.lateCount++
+		buffer.lock.Unlock()
+		deliver()
+		return
+	}
+	buffer.sequence++
+	heap.Push(&state.pending, reorderEvent{
+		timestampNs: timestampNs,
+		received:    now,
+		sequence:    buffer.sequence,
+		deliver:     deliver,
+	})
+	buffer.lock.Unlock()
+}
+
+func (buffer *reorderBuffer) collect(cutoff time.Time, all bool) []func() {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	ready := make([]func(), 0)
+	idleCutoff := cutoff.Add(-REORDER_IDLE_TIMEOUT)
+	for symbol, state := range buffer.symbols {
+		for (len(state.pending) > 0) && (all || !state.pending[0].received.After(cutoff)) {
+			event := heap.Pop(&state.pending).(reorderEvent)
+			state.lastReleased = event.timestampNs
+			ready = append(ready, event.deliver)
+		}
+		if (len(state.pending) == 0) && state.lastActive.Before(idleCutoff) {
+			delete(buffer.symbols, symbol)
+		}
+	}
+	return ready
+}
+
+func (buffer *reorderBuffer) getLateCount() uint64 {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.lateCount
+}
+
+func (buffer *reorderBuffer) start() {
+	if atomic.CompareAndSwapUint32(&buffer.running, 0, 1) {
+		go buffer.run()
+	}
+}
+
+func (buffer *reorderBuffer) run() {
+	interval := buffer.maxDelay / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-buffer.done:
+			for _, deliver := range buffer.collect(time.Now(), true) {
+				deliver()
+			}
+			buffer.done <- true
+			return
+		case now := <-ticker.C:
+			for _, deliver := range buffer.collect(now.Add(-buffer.maxDelay), false) {
+				deliver()
+			}
+		}
+	}
+}
+
+func (buffer *reorderBuffer) stop() {
+	if atomic.CompareAndSwapUint32(&buffer.running, 1, 0) {
+		buffer.done <- true
+		<-buffer.done
+	}
+}
+
+func (handlers equityHandlers) reordered(buffer *reorderBuffer) equityHandlers {
+	result := equityHandlers{}
+	if onTrade := handlers.onTrade; onTrade != nil {
+		result.onTrade = func(trade EquityTrade) {
+			buffer.push(trade.Symbol, trade.TimestampNs, func() { onTrade(trade) })
+		}
+	}
+	if onQuote := handlers.onQuote; onQuote != nil {
+		result.onQuote = func(quote EquityQuote) {
+			buffer.push(quote.Symbol, quote.TimestampNs, func() { onQuote(quote) })
+		}
+	}
+	return result
+}
+
+func (handlers optionHandlers) reordered(buffer *reorderBuffer) optionHandlers {
+	result := optionHandlers{onRefresh: handlers.onRefresh}
+	if onTrade := handlers.onTrade; onTrade != nil {
+		result.onTrade = func(trade OptionTrade) {
+			buffer.push(trade.ContractId, trade.TimestampNs, func() { onTrade(trade) })
+		}
+	}
+	if onQuote := handlers.onQuote; onQuote != nil {
+		result.onQuote = func(quote OptionQuote) {
+			buffer.push(quote.ContractId, quote.TimestampNs, func() { onQuote(quote) })
+		}
+	}
+	if onUnusualActivity := handlers.onUnusualActivity; onUnusualActivity != nil {
+		result.onUnusualActivity = func(ua OptionUnusualActivity) {
+			buffer.push(ua.ContractId, ua.TimestampNs, func() { onUnusualActivity(ua) })
+		}
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package intrinio
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ReplayConfig struct {
+	Filename string
+	Speed    float64
+	Start    time.Time
+	End      time.Time
+	Symbols  []string
+}
+
+type ReplayClient struct {
+	config          ReplayConfig
+	isOptions       bool
+	work            func([]byte)
+	logger          Logger
+	isStopped       uint32
+	frameCount      uint64
+	firstReceivedNs int64
+	start           time.Time
+	doneWg          sync.WaitGroup
+	err             error
+}
+
+func NewEquitiesReplayClient(
+	c ReplayConfig,
+	onTrade func(EquityTrade),
+	onQuote func(EquityQuote)) *ReplayClient {
+	replayClient := &ReplayClient{
+		config: c,
+		logger: defaultLogger,
+	}
+	replayClient.work = func(data []byte) {
+		workOnEquities(data, func([]byte) {}, replayClient.logger, NewEquityDispatcher(onTrade, onQuote), func(SubProvider) bool { return true }, func(eventKind) {}, nil)
+	}
+	return replayClient
+}
+
+func NewOptionsReplayClient(
+	c ReplayConfig,
+	onTrade func(OptionTrade),
+	onQuote func(OptionQuote),
+	onRefresh func(OptionRefresh),
+	onUnusualActivity func(OptionUnusualActivity)) *ReplayClient {
+	replayClient := &ReplayClient{
+		config:    c,
+		isOptions: true,
+		logger:    defaultLogger,
+	}
+	replayClient.work = func(data []byte) {
+		workOnOptions(data, func([]byte) {}, replayClient.logger, NewOptionDispatcher(onTrade, onQuote, onRefresh, onUnusualActivity), func(eventKind) {}, nil)
+	}
+	return replayClient
+}
+
+func (replayClient *ReplayClient) SetLogger(logger Logger) {
+	replayClient.logger = logger
+}
+
+func (replayClient *ReplayClient) Start() error {
+	file, openErr := os.Open(replayClient.config.Filename)
+	if openErr != nil {
+		return openErr
+	}
+	magic := make([]byte, len(CAPTURE_MAGIC))
+	if _, readErr := io.ReadFull(file, magic); readErr != nil {
+		file.Close()
+		return ErrInvalidCapture
+	}
+	query := ArchiveQuery{
+		Start:   replayClient.config.Start,
+		End:     replayClient.config.End,
+		Symbols: replayClient.config.Symbols,
+	}
+	var replay func() error
+	switch string(magic) {
+	case CAPTURE_MAGIC:
+		reader := bufio.NewReader(file)
+		replay = func() error {
+			return replayClient.replayCapture(reader, query)
+		}
+	case ARCHIVE_MAGIC:
+		index, indexErr := readArchiveIndex(file)
+		if indexErr != nil {
+			file.Close()
+			return indexErr
+		}
+		archiveReader := &ArchiveReader{file: file, index: index}
+		replay = func() error {
+			return archiveReader.Scan(query, replayClient.deliver)
+		}
+	default:
+		file.Close()
+		return ErrInvalidCapture
+	}
+	replayClient.start = time.Time{}
+	atomic.StoreUint32(&replayClient.isStopped, 0)
+	replayClient.doneWg.Add(1)
+	go func() {
+		defer replayClient.doneWg.Done()
+		defer file.Close()
+		replayClient.err = replay()
+		if replayClient.err != nil {
+			replayClient.logger.Error("Replay Client - Replay failed: %v\n", replayClient.err)
+		} else {
+			replayClient.logger.Info("Replay Client - Replayed %d frames\n", atomic.LoadUint64(&replayClient.frameCount))
+		}
+	}()
+	return nil
+}
+
+func (replayClient *ReplayClient) replayCapture(reader io.Reader, query ArchiveQuery) error {
+	for atomic.LoadUint32(&replayClient.isStopped) == 0 {
+		frame, readErr := readCaptureFrame(reader)
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if query.matchFrame(frame, replayClient.isOptions) && !replayClient.deliver(frame) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (replayClient *ReplayClient) deliver(frame CaptureFrame) bool {
+	if atomic.LoadUint32(&replayClient.isStopped) != 0 {
+		return false
+	}
+	if (len(frame.Data) == 0) || (frame.Data[0] == 0) {
+		return true
+	}
+	if replayClient.config.Speed > 0 {
+		if replayClient.start.IsZero() {
+			replayClient.firstReceivedNs = frame.ReceivedNs
+			replayClient.start = time.Now()
+		}
+		offset := time.Duration(float64(frame.ReceivedNs-replayClient.firstReceivedNs) / replayClient.config.Speed)
+		if wait := time.Until(replayClient.start.Add(offset)); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	replayClient.work(frame.Data)
+	atomic.AddUint64(&replayClient.frameCount, 1)
+	return true
+}
+
+func (replayClient *ReplayClient) Wait() error {
+	replayClient.doneWg.Wait()
+	return replayClient.err
+}
+
+func (replayClient *ReplayClient) Stop() {
+	atomic.StoreUint32(&replayClient.isStopped, 1)
+	replayClient.doneWg.Wait()
+}
+
+func (replayClient *ReplayClient) GetFrameCount() uint64 {
+	return atomic.LoadUint64(&replayClient.frameCount)
+}
@@ -0,0 +1,212 @@
+package intrinio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	REST_BASE_URL             string = "https://api-v2.intrinio.com"
+	REST_MAX_RETRIES          int    = 2
+	REST_RETRY_DELAY_MS       int    = 500
+	REST_BREAKER_COOLDOWN_SEC int    = 30
+)
+
+var ErrRestSuspended = errors.New("Client - REST requests suspended after repeated failures")
+
+type RestPolicy struct {
+	MaxRetries             int
+	RetryDelayMs           int
+	MinIntervalMs          int
+	FailureThreshold       int
+	BreakerCooldownSeconds int
+}
+
+type RestStats struct {
+	RequestCount  uint64
+	RetryCount    uint64
+	FailureCount  uint64
+	RejectedCount uint64
+	Suspended     bool
+}
+
+type restStatusError struct {
+	statusCode int
+	status     string
+}
+
+func (err restStatusError) Error() string {
+	return fmt.Sprintf("Client - REST request failure: %s", err.status)
+}
+
+func isRetryable(err error) bool {
+	var statusErr restStatusError
+	if errors.As(err, &statusErr) {
+		return (statusErr.statusCode == http.StatusTooManyRequests) || (statusErr.statusCode >= 500)
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+func (policy RestPolicy) getMaxRetries() int {
+	if policy.MaxRetries == 0 {
+		return REST_MAX_RETRIES
+	} else if policy.MaxRetries < 0 {
+		return 0
+	}
+	return policy.MaxRetries
+}
+
+func (policy RestPolicy) getRetryDelay() time.Duration {
+	if policy.RetryDelayMs <= 0 {
+		return time.Duration(REST_RETRY_DELAY_MS) * time.Millisecond
+	}
+	return time.Duration(policy.RetryDelayMs) * time.Millisecond
+}
+
+func (policy RestPolicy) getMinInterval() time.Duration {
+	if policy.MinIntervalMs <= 0 {
+		return 0
+	}
+	return time.Duration(policy.MinIntervalMs) * time.Millisecond
+}
+
+func (policy RestPolicy) getBreakerCooldown() time.Duration {
+	if policy.BreakerCooldownSeconds <= 0 {
+		return time.Duration(REST_BREAKER_COOLDOWN_SEC) * time.Second
+	}
+	return time.Duration(policy.BreakerCooldownSeconds) * time.Second
+}
+
+type restClient struct {
+	httpClient    *http.Client
+	apiKey        string
+	lock          sync.Mutex
+	header        http.Header
+	policy        RestPolicy
+	nextRequest   time.Time
+	failures      int
+	suspendUntil  time.Time
+	requestCount  uint64
+	retryCount    uint64
+	failureCount  uint64
+	rejectedCount uint64
+}
+
+func newRestClient(httpClient *http.Client, apiKey string, header http.Header, policy RestPolicy) *restClient {
+	return &restClient{
+		httpClient: httpClient,
+		apiKey:     apiKey,
+		header:     header,
+		policy:     policy,
+	}
+}
+
+func (restClient *restClient) configure(header http.Header, policy RestPolicy) {
+	restClient.lock.Lock()
+	restClient.header = header
+	restClient.policy = policy
+	restClient.lock.Unlock()
+}
+
+func (restClient *restClient) getStats() RestStats {
+	restClient.lock.Lock()
+	suspended := time.Now().Before(restClient.suspendUntil)
+	restClient.lock.Unlock()
+	return RestStats{
+		RequestCount:  atomic.LoadUint64(&restClient.requestCount),
+		RetryCount:    atomic.LoadUint64(&restClient.retryCount),
+		FailureCount:  atomic.LoadUint64(&restClient.failureCount),
+		RejectedCount: atomic.LoadUint64(&restClient.rejectedCount),
+		Suspended:     suspended,
+	}
+}
+
+func (restClient *restClient) acquire() (http.Header, RestPolicy, error) {
+	restClient.lock.Lock()
+	now := time.Now()
+	if now.Before(restClient.suspendUntil) {
+		restClient.lock.Unlock()
+		atomic.AddUint64(&restClient.rejectedCount, 1)
+		return nil, RestPolicy{}, ErrRestSuspended
+	}
+	header := restClient.header
+	policy := restClient.policy
+	wait := restClient.nextRequest.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	restClient.nextRequest = now.Add(wait + policy.getMinInterval())
+	restClient.lock.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return header, policy, nil
+}
+
+func (restClient *restClient) release(err error) {
+	restClient.lock.Lock()
+	defer restClient.lock.Unlock()
+	if err == nil {
+		restClient.failures = 0
+		return
+	}
+	atomic.AddUint64(&restClient.failureCount, 1)
+	restClient.failures++
+	if (restClient.policy.FailureThreshold > 0) && (restClient.failures >= restClient.policy.FailureThreshold) {
+		restClient.failures = 0
+		restClient.suspendUntil = time.Now().Add(restClient.policy.getBreakerCooldown())
+	}
+}
+
+func (restClient *restClient) getJSON(path string, query url.Values, result any) error {
+	var fetchErr error
+	for attempt := 0; ; attempt++ {
+		header, policy, acquireErr := restClient.acquire()
+		if acquireErr != nil {
+			return acquireErr
+		}
+		atomic.AddUint64(&restClient.requestCount, 1)
+		fetchErr = getRestJSON(restClient.httpClient, restClient.apiKey, header, path, query, result)
+		if (fetchErr == nil) || !isRetryable(fetchErr) || (attempt >= policy.getMaxRetries()) {
+			break
+		}
+		atomic.AddUint64(&restClient.retryCount, 1)
+		time.Sleep(policy.getRetryDelay() << attempt)
+	}
+	restClient.release(fetchErr)
+	return fetchErr
+}
+
+func (client *Client) GetRestStats() RestStats {
+	return client.rest.getStats()
+}
+
+func getRestJSON(httpClient *http.Client, apiKey string, header http.Header, path string, query url.Values, result any) error {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set("api_key", apiKey)
+	req, httpNewReqErr := http.NewRequest("GET", REST_BASE_URL+path+"?"+params.Encode(), nil)
+	if httpNewReqErr != nil {
+		return httpNewReqErr
+	}
+	req.Header = header.Clone()
+	resp, httpDoErr := httpClient.Do(req)
+	if httpDoErr != nil {
+		return httpDoErr
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return restStatusError{statusCode: resp.StatusCode, status: resp.Status}
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
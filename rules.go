@@ -0,0 +1,413 @@
+package intrinio
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidRule = errors.New("Client - Invalid rule")
+
+type RuleEvent interface {
+	EquityTrade | EquityQuote | OptionTrade | OptionQuote | OptionRefresh | OptionUnusualActivity
+}
+
+type ruleKind uint8
+
+const (
+	ruleNumber ruleKind = iota
+	ruleString
+	ruleBool
+)
+
+func (kind ruleKind) String() string {
+	switch kind {
+	case ruleNumber:
+		return "number"
+	case ruleString:
+		return "string"
+	}
+	return "bool"
+}
+
+type ruleNode[T RuleEvent] struct {
+	kind    ruleKind
+	number  func(T) float64
+	text    func(T) string
+	boolean func(T) bool
+}
+
+func numberField[T RuleEvent](fn func(T) float64) ruleNode[T] {
+	return ruleNode[T]{kind: ruleNumber, number: fn}
+}
+
+func textField[T RuleEvent](fn func(T) string) ruleNode[T] {
+	return ruleNode[T]{kind: ruleString, text: fn}
+}
+
+func boolField[T RuleEvent](fn func(T) bool) ruleNode[T] {
+	return ruleNode[T]{kind: ruleBool, boolean: fn}
+}
+
+func getRuleFields[T RuleEvent]() map[string]any {
+	var zero T
+	switch any(zero).(type) {
+	case EquityTrade:
+		return map[string]any{
+			"symbol":          textField(func(t EquityTrade) string { return t.Symbol }),
+			"price":           numberField(func(t EquityTrade) float64 { return float64(t.Price) }),
+			"size":            numberField(func(t EquityTrade) float64 { return float64(t.Size) }),
+			"totalvolume":     numberField(func(t EquityTrade) float64 { return float64(t.TotalVolume) }),
+			"timestamp":       numberField(func(t EquityTrade) float64 { return t.Timestamp }),
+			"conditions":      textField(func(t EquityTrade) string { return t.Conditions }),
+			"marketcenter":    textField(func(t EquityTrade) string { return string(t.MarketCenter) }),
+			"subprovider":     textField(func(t EquityTrade) string { return t.GetSubProvider().String() }),
+			"tape":            textField(func(t EquityTrade) string { return t.GetTape().String() }),
+			"eligibleforlast": boolField(func(t EquityTrade) bool { return t.IsEligibleForLast() }),
+			"extendedhours":   boolField(func(t EquityTrade) bool { return t.IsExtendedHours() }),
+			"oddlot":          boolField(func(t EquityTrade) bool { return t.IsOddLot() }),
+		}
+	case EquityQuote:
+		return map[string]any{
+			"symbol":       textField(func(q EquityQuote) string { return q.Symbol }),
+			"price":        numberField(func(q EquityQuote) float64 { return float64(q.Price) }),
+			"size":         numberField(func(q EquityQuote) float64 { return float64(q.Size) }),
+			"timestamp":    numberField(func(q EquityQuote) float64 { return q.Timestamp }),
+			"conditions":   textField(func(q EquityQuote) string { return q.Conditions }),
+			"marketcenter": textField(func(q EquityQuote) string { return string(q.MarketCenter) }),
+			"subprovider":  textField(func(q EquityQuote) string { return q.GetSubProvider().String() }),
+			"tape":         textField(func(q EquityQuote) string { return q.GetTape().String() }),
+			"isask":        boolField(func(q EquityQuote) bool { return q.Type == ASK }),
+			"isbid":        boolField(func(q EquityQuote) bool { return q.Type == BID }),
+		}
+	case OptionTrade:
+		return map[string]any{
+			"contract":        textField(func(t OptionTrade) string { return t.ContractId }),
+			"underlying":      textField(func(t OptionTrade) string { return t.GetUnderlyingSymbol() }),
+			"exchange":        textField(func(t OptionTrade) string { return t.Exchange.String() }),
+			"price":           numberField(func(t OptionTrade) float64 { return float64(t.Price) }),
+			"size":            numberField(func(t OptionTrade) float64 { return float64(t.Size) }),
+			"totalvolume":     numberField(func(t OptionTrade) float64 { return float64(t.TotalVolume) }),
+			"ask":             numberField(func(t OptionTrade) float64 { return float64(t.AskPriceAtExecution) }),
+			"bid":             numberField(func(t OptionTrade) float64 { return float64(t.BidPriceAtExecution) }),
+			"underlyingprice": numberField(func(t OptionTrade) float64 { return float64(t.UnderlyingPriceAtExecution) }),
+			"strike":          numberField(func(t OptionTrade) float64 { return float64(t.GetStrikePrice()) }),
+			"timestamp":       numberField(func(t OptionTrade) float64 { return t.Timestamp }),
+			"isput":           boolField(func(t OptionTrade) bool { return t.IsPut() }),
+			"iscall":          boolField(func(t OptionTrade) bool { return t.IsCall() }),
+		}
+	case OptionQuote:
+		return map[string]any{
+			"contract":   textField(func(q OptionQuote) string { return q.ContractId }),
+			"underlying": textField(func(q OptionQuote) string { return q.GetUnderlyingSymbol() }),
+			"ask":        numberField(func(q OptionQuote) float64 { return float64(q.AskPrice) }),
+			"bid":        numberField(func(q OptionQuote) float64 { return float64(q.BidPrice) }),
+			"asksize":    numberField(func(q OptionQuote) float64 { return float64(q.AskSize) }),
+			"bidsize":    numberField(func(q OptionQuote) float64 { return float64(q.BidSize) }),
+			"strike":     numberField(func(q OptionQuote) float64 { return float64(q.GetStrikePrice()) }),
+			"timestamp":  numberField(func(q OptionQuote) float64 { return q.Timestamp }),
+			"isput":      boolField(func(q OptionQuote) bool { return q.IsPut() }),
+			"iscall":     boolField(func(q OptionQuote) bool { return q.IsCall() }),
+		}
+	case OptionRefresh:
+		return map[string]any{
+			"contract":     textField(func(r OptionRefresh) string { return r.ContractId }),
+			"underlying":   textField(func(r OptionRefresh) string { return r.GetUnderlyingSymbol() }),
+			"openinterest": numberField(func(r OptionRefresh) float64 { return float64(r.OpenInterest) }),
+			"open":         numberField(func(r OptionRefresh) float64 { return float64(r.OpenPrice) }),
+			"close":        numberField(func(r OptionRefresh) float64 { return float64(r.ClosePrice) }),
+			"high":         numberField(func(r OptionRefresh) float64 { return float64(r.HighPrice) }),
+			"low":          numberField(func(r OptionRefresh) float64 { return float64(r.LowPrice) }),
+			"strike":       numberField(func(r OptionRefresh) float64 { return float64(r.GetStrikePrice()) }),
+			"isput":        boolField(func(r OptionRefresh) bool { return r.IsPut() }),
+			"iscall":       boolField(func(r OptionRefresh) bool { return r.IsCall() }),
+		}
+	case OptionUnusualActivity:
+		return map[string]any{
+			"contract":        textField(func(ua OptionUnusualActivity) string { return ua.ContractId }),
+			"underlying":      textField(func(ua OptionUnusualActivity) string { return ua.GetUnderlyingSymbol() }),
+			"type":            numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.Type) }),
+			"sentiment":       numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.Sentiment) }),
+			"totalvalue":      numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.TotalValue) }),
+			"totalsize":       numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.TotalSize) }),
+			"averageprice":    numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.AveragePrice) }),
+			"ask":             numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.AskPriceAtExecution) }),
+			"bid":             numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.BidPriceAtExecution) }),
+			"underlyingprice": numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.UnderlyingPriceAtExecution) }),
+			"strike":          numberField(func(ua OptionUnusualActivity) float64 { return float64(ua.GetStrikePrice()) }),
+			"timestamp":       numberField(func(ua OptionUnusualActivity) float64 { return ua.Timestamp }),
+			"isput":           boolField(func(ua OptionUnusualActivity) bool { return ua.IsPut() }),
+			"iscall":          boolField(func(ua OptionUnusualActivity) bool { return ua.IsCall() }),
+		}
+	}
+	return map[string]any{}
+}
+
+type Rule[T RuleEvent] struct {
+	expression string
+	match      func(T) bool
+}
+
+func (rule *Rule[T]) String() string {
+	return rule.expression
+}
+
+func (rule *Rule[T]) Match(event T) bool {
+	return rule.match(event)
+}
+
+func CompileRule[T RuleEvent](expression string) (*Rule[T], error) {
+	tokens, lexErr := lexRule(expression)
+	if lexErr != nil {
+		return nil, lexErr
+	}
+	parser := &ruleParser[T]{tokens: tokens, fields: getRuleFields[T]()}
+	node, parseErr := parser.parseOr()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("%w: unexpected token '%s' in: %s", ErrInvalidRule, parser.tokens[parser.position].text, expression)
+	}
+	if node.kind != ruleBool {
+		return nil, fmt.Errorf("%w: expression must evaluate to a bool, not a %s: %s", ErrInvalidRule, node.kind, expression)
+	}
+	return &Rule[T]{expression: expression, match: node.boolean}, nil
+}
+
+type ruleTokenType uint8
+
+const (
+	ruleTokenNumber ruleTokenType = iota
+	ruleTokenString
+	ruleTokenIdent
+	ruleTokenOperator
+)
+
+type ruleToken struct {
+	tokenType ruleTokenType
+	text      string
+	number    float64
+}
+
+func lexRule(expression string) ([]ruleToken, error) {
+	tokens := make([]ruleToken, 0)
+	i := 0
+	for i < len(expression) {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case (c >= '0' && c <= '9') || c == '.' || (c == '-' && i+1 < len(expression) && ((expression[i+1] >= '0' && expression[i+1] <= '9') || expression[i+1] == '.')):
+			start := i
+			i++
+			for i < len(expression) && ((expression[i] >= '0' && expression[i] <= '9') || expression[i] == '.') {
+				i++
+			}
+			number, parseErr := strconv.ParseFloat(expression[start:i], 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("%w: invalid number '%s' in: %s", ErrInvalidRule, expression[start:i], expression)
+			}
+			tokens = append(tokens, ruleToken{tokenType: ruleTokenNumber, text: expression[start:i], number: number})
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated string in: %s", ErrInvalidRule, expression)
+			}
+			tokens = append(tokens, ruleToken{tokenType: ruleTokenString, text: expression[i+1 : i+1+end]})
+			i = i + end + 2
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_':
+			start := i
+			for i < len(expression) && ((expression[i] >= 'a' && expression[i] <= 'z') || (expression[i] >= 'A' && expression[i] <= 'Z') || (expression[i] >= '0' && expression[i] <= '9') || expression[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, ruleToken{tokenType: ruleTokenIdent, text: strings.ToLower(expression[start:i])})
+		default:
+			operator := ""
+			if i+1 < len(expression) {
+				switch expression[i : i+2] {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					operator = expression[i : i+2]
+				}
+			}
+			if operator == "" {
+				switch c {
+				case '<', '>', '!', '(', ')':
+					operator = string(c)
+				default:
+					return nil, fmt.Errorf("%w: unexpected character '%c' in: %s", ErrInvalidRule, c, expression)
+				}
+			}
+			tokens = append(tokens, ruleToken{tokenType: ruleTokenOperator, text: operator})
+			i = i + len(operator)
+		}
+	}
+	return tokens, nil
+}
+
+type ruleParser[T RuleEvent] struct {
+	tokens   []ruleToken
+	position int
+	fields   map[string]any
+}
+
+func (parser *ruleParser[T]) peekOperator(operator string) bool {
+	return parser.position < len(parser.tokens) &&
+		parser.tokens[parser.position].tokenType == ruleTokenOperator &&
+		parser.tokens[parser.position].text == operator
+}
+
+func (parser *ruleParser[T]) parseOr() (ruleNode[T], error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for parser.peekOperator("||") {
+		parser.position++
+		right, rightErr := parser.parseAnd()
+		if rightErr != nil {
+			return right, rightErr
+		}
+		if left.kind != ruleBool || right.kind != ruleBool {
+			return left, fmt.Errorf("%w: '||' requires bool operands", ErrInvalidRule)
+		}
+		l, r := left.boolean, right.boolean
+		left = boolField(func(event T) bool { return l(event) || r(event) })
+	}
+	return left, nil
+}
+
+func (parser *ruleParser[T]) parseAnd() (ruleNode[T], error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	for parser.peekOperator("&&") {
+		parser.position++
+		right, rightErr := parser.parseUnary()
+		if rightErr != nil {
+			return right, rightErr
+		}
+		if left.kind != ruleBool || right.kind != ruleBool {
+			return left, fmt.Errorf("%w: '&&' requires bool operands", ErrInvalidRule)
+		}
+		l, r := left.boolean, right.boolean
+		left = boolField(func(event T) bool { return l(event) && r(event) })
+	}
+	return left, nil
+}
+
+func (parser *ruleParser[T]) parseUnary() (ruleNode[T], error) {
+	if parser.peekOperator("!") {
+		parser.position++
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return operand, err
+		}
+		if operand.kind != ruleBool {
+			return operand, fmt.Errorf("%w: '!' requires a bool operand", ErrInvalidRule)
+		}
+		o := operand.boolean
+		return boolField(func(event T) bool { return !o(event) }), nil
+	}
+	return parser.parseComparison()
+}
+
+func (parser *ruleParser[T]) parseComparison() (ruleNode[T], error) {
+	left, err := parser.parseOperand()
+	if err != nil {
+		return left, err
+	}
+	if parser.position >= len(parser.tokens) || parser.tokens[parser.position].tokenType != ruleTokenOperator {
+		return left, nil
+	}
+	operator := parser.tokens[parser.position].text
+	switch operator {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	parser.position++
+	right, rightErr := parser.parseOperand()
+	if rightErr != nil {
+		return right, rightErr
+	}
+	if left.kind != right.kind {
+		return left, fmt.Errorf("%w: cannot compare %s with %s", ErrInvalidRule, left.kind, right.kind)
+	}
+	switch left.kind {
+	case ruleNumber:
+		l, r := left.number, right.number
+		switch operator {
+		case "==":
+			return boolField(func(event T) bool { return l(event) == r(event) }), nil
+		case "!=":
+			return boolField(func(event T) bool { return l(event) != r(event) }), nil
+		case "<":
+			return boolField(func(event T) bool { return l(event) < r(event) }), nil
+		case "<=":
+			return boolField(func(event T) bool { return l(event) <= r(event) }), nil
+		case ">":
+			return boolField(func(event T) bool { return l(event) > r(event) }), nil
+		default:
+			return boolField(func(event T) bool { return l(event) >= r(event) }), nil
+		}
+	case ruleString:
+		l, r := left.text, right.text
+		switch operator {
+		case "==":
+			return boolField(func(event T) bool { return l(event) == r(event) }), nil
+		case "!=":
+			return boolField(func(event T) bool { return l(event) != r(event) }), nil
+		}
+	default:
+		l, r := left.boolean, right.boolean
+		switch operator {
+		case "==":
+			return boolField(func(event T) bool { return l(event) == r(event) }), nil
+		case "!=":
+			return boolField(func(event T) bool { return l(event) != r(event) }), nil
+		}
+	}
+	return left, fmt.Errorf("%w: operator '%s' is not supported for %s operands", ErrInvalidRule, operator, left.kind)
+}
+
+func (parser *ruleParser[T]) parseOperand() (ruleNode[T], error) {
+	if parser.position >= len(parser.tokens) {
+		return ruleNode[T]{}, fmt.Errorf("%w: unexpected end of expression", ErrInvalidRule)
+	}
+	token := parser.tokens[parser.position]
+	parser.position++
+	switch token.tokenType {
+	case ruleTokenNumber:
+		value := token.number
+		return numberField(func(T) float64 { return value }), nil
+	case ruleTokenString:
+		value := token.text
+		return textField(func(T) string { return value }), nil
+	case ruleTokenIdent:
+		if token.text == "true" || token.text == "false" {
+			value := token.text == "true"
+			return boolField(func(T) bool { return value }), nil
+		}
+		field, ok := parser.fields[token.text]
+		if !ok {
+			return ruleNode[T]{}, fmt.Errorf("%w: unknown field '%s'", ErrInvalidRule, token.text)
+		}
+		return field.(ruleNode[T]), nil
+	default:
+		if token.text == "(" {
+			node, err := parser.parseOr()
+			if err != nil {
+				return node, err
+			}
+			if !parser.peekOperator(")") {
+				return node, fmt.Errorf("%w: missing ')'", ErrInvalidRule)
+			}
+			parser.position++
+			return node, nil
+		}
+		return ruleNode[T]{}, fmt.Errorf("%w: unexpected token '%s'", ErrInvalidRule, token.text)
+	}
+}
@@ -0,0 +1,75 @@
+package intrinio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompileRuleMatches(t *testing.T) {
+	cases := []struct {
+		expression string
+		trade      EquityTrade
+		expected   bool
+	}{
+		{"price > 100", EquityTrade{Price: 101}, true},
+		{"price > 100", EquityTrade{Price: 100}, false},
+		{"price >= 100 && size < 500", EquityTrade{Price: 100, Size: 499}, true},
+		{"price != 100", EquityTrade{Price: 100}, false},
+		{"symbol == 'AAPL'", EquityTrade{Symbol: "AAPL"}, true},
+		{"symbol != \"AAPL\"", EquityTrade{Symbol: "AAPL"}, false},
+		{"symbol == 'A' || symbol == 'B' && price > 10", EquityTrade{Symbol: "A", Price: 5}, true},
+		{"symbol == 'A' || symbol == 'B' && price > 10", EquityTrade{Symbol: "B", Price: 5}, false},
+		{"(symbol == 'A' || symbol == 'B') && price > 10", EquityTrade{Symbol: "A", Price: 5}, false},
+		{"!(price > 10) && size > 0", EquityTrade{Price: 5, Size: 1}, true},
+		{"!(price > 10 && size > 0)", EquityTrade{Price: 11, Size: 1}, false},
+		{"oddlot == false", EquityTrade{Size: 100}, true},
+		{"price > -0.5", EquityTrade{Price: -0.25}, true},
+		{"price > -0.5", EquityTrade{Price: -0.75}, false},
+		{"price < -.25", EquityTrade{Price: -0.5}, true},
+		{"price>-1&&price<1", EquityTrade{Price: 0}, true},
+	}
+	for _, c := range cases {
+		rule, compileErr := CompileRule[EquityTrade](c.expression)
+		if compileErr != nil {
+			t.Errorf("%s: unexpected error: %v", c.expression, compileErr)
+			continue
+		}
+		if matched := rule.Match(c.trade); matched != c.expected {
+			t.Errorf("%s: expected %v for %+v, got %v", c.expression, c.expected, c.trade, matched)
+		}
+	}
+}
+
+func TestCompileRuleRejectsInvalidExpressions(t *testing.T) {
+	expressions := []string{
+		"",
+		"price",
+		"volume > 100",
+		"price > 'AAPL'",
+		"symbol > 'AAPL'",
+		"price > 100 &&",
+		"(price > 100",
+		"price > 100)",
+		"price - 1 > 0",
+		"price > --1",
+		"price > 1.2.3",
+		"symbol == 'AAPL",
+		"!price",
+		"price > 1 || size",
+		"price > 1 @ size > 1",
+	}
+	for _, expression := range expressions {
+		if _, compileErr := CompileRule[EquityTrade](expression); !errors.Is(compileErr, ErrInvalidRule) {
+			t.Errorf("%s: expected ErrInvalidRule, got %v", expression, compileErr)
+		}
+	}
+}
+
+func TestCompileRuleFieldsByEventType(t *testing.T) {
+	if _, compileErr := CompileRule[OptionTrade]("strike > 100 && iscall"); compileErr != nil {
+		t.Fatalf("unexpected error: %v", compileErr)
+	}
+	if _, compileErr := CompileRule[EquityTrade]("strike > 100"); !errors.Is(compileErr, ErrInvalidRule) {
+		t.Fatalf("expected ErrInvalidRule, got %v", compileErr)
+	}
+}
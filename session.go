@@ -0,0 +1,126 @@
+package intrinio
+
+import (
+	"sync"
+	"time"
+)
+
+type SessionResetter interface {
+	ResetSession()
+}
+
+type SessionRollover struct {
+	SessionDate time.Time
+	Time        time.Time
+}
+
+type SessionRolloverConfig struct {
+	CloseHour   int
+	CloseMinute int
+	Location    *time.Location
+}
+
+var DefaultSessionRolloverConfig SessionRolloverConfig = SessionRolloverConfig{
+	CloseHour:   16,
+	CloseMinute: 0,
+}
+
+type SessionRolloverManager struct {
+	config     SessionRolloverConfig
+	lock       sync.Mutex
+	resetters  []SessionResetter
+	onRollover func(SessionRollover)
+	done       chan bool
+}
+
+func loadMarketLocation() *time.Location {
+	location, locationErr := time.LoadLocation("America/New_York")
+	if locationErr != nil {
+		defaultLogger.Warn("Client - Failure to load America/New_York time zone, using UTC: %v\n", locationErr)
+		return time.UTC
+	}
+	return location
+}
+
+func NewSessionRolloverManager(config SessionRolloverConfig, onRollover func(SessionRollover)) *SessionRolloverManager {
+	if (config.CloseHour < 0) || (config.CloseHour > 23) || (config.CloseMinute < 0) || (config.CloseMinute > 59) {
+		config.CloseHour = DefaultSessionRolloverConfig.CloseHour
+		config.CloseMinute = DefaultSessionRolloverConfig.CloseMinute
+	}
+	if config.Location == nil {
+		config.Location = loadMarketLocation()
+	}
+	return &SessionRolloverManager{
+		config:     config,
+		resetters:  make([]SessionResetter, 0),
+		onRollover: onRollover,
+	}
+}
+
+func (manager *SessionRolloverManager) Register(resetter SessionResetter) {
+	manager.lock.Lock()
+	manager.resetters = append(manager.resetters, resetter)
+	manager.lock.Unlock()
+}
+
+func (manager *SessionRolloverManager) GetNextRollover(now time.Time) time.Time {
+	local := now.In(manager.config.Location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), manager.config.CloseHour, manager.config.CloseMinute, 0, 0, manager.config.Location)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (manager *SessionRolloverManager) Rollover() {
+	now := time.Now().In(manager.config.Location)
+	manager.lock.Lock()
+	resetters := make([]SessionResetter, len(manager.resetters))
+	copy(resetters, manager.resetters)
+	manager.lock.Unlock()
+	for _, resetter := range resetters {
+		resetter.ResetSession()
+	}
+	defaultLogger.Info("Session Rollover - Session reset at %s\n", now.Format(time.RFC3339))
+	if manager.onRollover != nil {
+		manager.onRollover(SessionRollover{
+			SessionDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, manager.config.Location),
+			Time:        now,
+		})
+	}
+}
+
+func (manager *SessionRolloverManager) Start() {
+	manager.lock.Lock()
+	if manager.done != nil {
+		manager.lock.Unlock()
+		return
+	}
+	done := make(chan bool)
+	manager.done = done
+	manager.lock.Unlock()
+	go func() {
+		for {
+			timer := time.NewTimer(time.Until(manager.GetNextRollover(time.Now())))
+			select {
+			case <-done:
+				timer.Stop()
+				done <- true
+				return
+			case <-timer.C:
+				manager.Rollover()
+			}
+		}
+	}()
+}
+
+func (manager *SessionRolloverManager) Stop() {
+	manager.lock.Lock()
+	done := manager.done
+	manager.done = nil
+	manager.lock.Unlock()
+	if done != nil {
+		done <- true
+		<-done
+	}
+}
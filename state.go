@@ -0,0 +1,152 @@
+package intrinio
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const TOKEN_LIFETIME time.Duration = 24 * time.Hour
+
+var ErrNoState = errors.New("Client - No saved state")
+
+type ClientState struct {
+	Provider        Provider
+	Token           string
+	TokenUpdateTime time.Time
+	Subscriptions   map[string]SubscriptionOptions
+}
+
+type StateStore interface {
+	LoadState() (ClientState, error)
+	SaveState(ClientState) error
+}
+
+type FileStateStore struct {
+	filename string
+}
+
+func NewFileStateStore(filename string) *FileStateStore {
+	return &FileStateStore{filename: filename}
+}
+
+func (store *FileStateStore) LoadState() (ClientState, error) {
+	data, readErr := os.ReadFile(store.filename)
+	if errors.Is(readErr, os.ErrNotExist) {
+		return ClientState{}, ErrNoState
+	} else if readErr != nil {
+		return ClientState{}, readErr
+	}
+	var state ClientState
+	if unmarshalErr := json.Unmarshal(data, &state); unmarshalErr != nil {
+		return ClientState{}, unmarshalErr
+	}
+	return state, nil
+}
+
+func (store *FileStateStore) SaveState(state ClientState) error {
+	data, marshalErr := json.Marshal(state)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	temp, createErr := os.CreateTemp(filepath.Dir(store.filename), filepath.Base(store.filename)+".*.tmp")
+	if createErr != nil {
+		return createErr
+	}
+	defer os.Remove(temp.Name())
+	if _, writeErr := temp.Write(data); writeErr != nil {
+		temp.Close()
+		return writeErr
+	}
+	if closeErr := temp.Close(); closeErr != nil {
+		return closeErr
+	}
+	if chmodErr := os.Chmod(temp.Name(), 0600); chmodErr != nil {
+		return chmodErr
+	}
+	return os.Rename(temp.Name(), store.filename)
+}
+
+func (config Config) getStateStore() StateStore {
+	if config.StateFile == "" {
+		return nil
+	}
+	return NewFileStateStore(config.StateFile)
+}
+
+func (client *Client) SetStateStore(store StateStore) {
+	client.stateStore = store
+}
+
+func (client *Client) markStateDirty() {
+	if atomic.LoadUint32(&client.stateFrozen) == 0 {
+		atomic.StoreUint32(&client.stateDirty, 1)
+	}
+}
+
+func (client *Client) loadState() bool {
+	if client.stateStore == nil {
+		return false
+	}
+	state, loadErr := client.stateStore.LoadState()
+	if loadErr != nil {
+		if loadErr != ErrNoState {
+			client.logger.Warn("Client - Failure to load saved state: %v\n", loadErr)
+		}
+		return false
+	}
+	if state.Provider != client.getConfig().Provider {
+		client.logger.Info("Client - Ignoring saved state of provider %s\n", state.Provider)
+		return false
+	}
+	client.savedSubscriptions = state.Subscriptions
+	if (state.Token != "") && (time.Since(state.TokenUpdateTime) < TOKEN_LIFETIME) && (time.Since(client.tokenUpdateTime) >= TOKEN_LIFETIME) {
+		client.token = state.Token
+		client.tokenUpdateTime = state.TokenUpdateTime
+		client.logger.Info("Client - Using saved token")
+		return true
+	}
+	return false
+}
+
+func (client *Client) restoreSubscriptions() {
+	if len(client.savedSubscriptions) == 0 {
+		return
+	}
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	for _, symbol := range sortedKeys(client.savedSubscriptions) {
+		client.directJoins[symbol] = true
+		client.join(symbol, client.savedSubscriptions[symbol])
+	}
+	client.logger.Info("Client - Rejoined %d saved channels\n", len(client.savedSubscriptions))
+	client.savedSubscriptions = nil
+}
+
+func (client *Client) saveState(force bool) {
+	if client.stateStore == nil {
+		return
+	}
+	if !force && !atomic.CompareAndSwapUint32(&client.stateDirty, 1, 0) {
+		return
+	}
+	client.subscriptionsLock.Lock()
+	state := ClientState{
+		Provider:        client.getConfig().Provider,
+		Token:           client.token,
+		TokenUpdateTime: client.tokenUpdateTime,
+		Subscriptions:   make(map[string]SubscriptionOptions, len(client.directJoins)),
+	}
+	for symbol := range client.directJoins {
+		if options, ok := client.subscriptions[symbol]; ok {
+			state.Subscriptions[symbol] = options
+		}
+	}
+	client.subscriptionsLock.Unlock()
+	if saveErr := client.stateStore.SaveState(state); saveErr != nil {
+		client.logger.Warn("Client - Failure to save state: %v\n", saveErr)
+	}
+}
@@ -0,0 +1,156 @@
+package intrinio
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+type eventKind uint8
+
+const (
+	tradeEvent eventKind = iota
+	quoteEvent
+	refreshEvent
+	unusualActivityEvent
+	invalidEvent
+	eventKindCount
+)
+
+type StatsReport struct {
+	Time                 time.Time
+	IntervalSeconds      float64
+	Connected            bool
+	DataMsgCount         uint64
+	TextMsgCount         uint32
+	BytesReceived        uint64
+	LastMessageTime      time.Time
+	TradeCount           uint64
+	QuoteCount           uint64
+	RefreshCount         uint64
+	UnusualActivityCount uint64
+	ParseErrorCount      uint64
+	QueueDepth           int
+	QueueHighWatermark   int
+	ReconnectCount       uint64
+	DroppedFrameCount    uint64
+	DroppedEventCount    uint64
+	DataMsgRate          float64
+	TextMsgRate          float64
+	SubProviderCounts    map[string]uint64 `json:",omitempty"`
+	LateEventCount       uint64            `json:",omitempty"`
+	BlockedJoinCount     uint64            `json:",omitempty"`
+	StaleCount           uint64            `json:",omitempty"`
+	OverflowDepth        int               `json:",omitempty"`
+	OverflowBytes        int64             `json:",omitempty"`
+	OverflowGrowCount    uint64            `json:",omitempty"`
+	OverflowShrinkCount  uint64            `json:",omitempty"`
+	OverflowDropCount    uint64            `json:",omitempty"`
+}
+
+func (client *Client) SetOnStatsReport(onStatsReport func(StatsReport)) {
+	client.onStatsReport.Store(onStatsReport)
+}
+
+func (client *Client) getStatsReport(previous StatsReport) StatsReport {
+	report := StatsReport{
+		Time:                 time.Now(),
+		Connected:            !client.isStopped && !client.isClosed,
+		DataMsgCount:         atomic.LoadUint64(&client.dataMsgCount),
+		TextMsgCount:         atomic.LoadUint32(&client.txtMsgCount),
+		BytesReceived:        atomic.LoadUint64(&client.bytesReceived),
+		TradeCount:           atomic.LoadUint64(&client.eventCounts[tradeEvent]),
+		QuoteCount:           atomic.LoadUint64(&client.eventCounts[quoteEvent]),
+		RefreshCount:         atomic.LoadUint64(&client.eventCounts[refreshEvent]),
+		UnusualActivityCount: atomic.LoadUint64(&client.eventCounts[unusualActivityEvent]),
+		ParseErrorCount:      atomic.LoadUint64(&client.eventCounts[invalidEvent]),
+		QueueDepth:           len(client.readChannel),
+		QueueHighWatermark:   int(atomic.LoadInt64(&client.queueHighWatermark)),
+		ReconnectCount:       atomic.LoadUint64(&client.reconnectCount),
+		DroppedFrameCount:    atomic.LoadUint64(&client.droppedFrameCount),
+		DroppedEventCount:    atomic.LoadUint64(&client.droppedEventCount),
+		BlockedJoinCount:     atomic.LoadUint64(&client.blockedJoinCount),
+		StaleCount:           atomic.LoadUint64(&client.staleCount),
+	}
+	if lastMessageTime := atomic.LoadInt64(&client.lastMessageTime); lastMessageTime > 0 {
+		report.LastMessageTime = time.Unix(0, lastMessageTime)
+	}
+	if !previous.Time.IsZero() {
+		report.IntervalSeconds = report.Time.Sub(previous.Time).Seconds()
+		if report.IntervalSeconds > 0 {
+			report.DataMsgRate = float64(report.DataMsgCount-previous.DataMsgCount) / report.IntervalSeconds
+			report.TextMsgRate = float64(report.TextMsgCount-previous.TextMsgCount) / report.IntervalSeconds
+		}
+	}
+	if client.reorderBuffer != nil {
+		report.LateEventCount = client.reorderBuffer.getLateCount()
+	}
+	client.addOverflowStats(&report)
+	if counts := client.GetSubProviderCounts(); len(counts) > 0 {
+		report.SubProviderCounts = make(map[string]uint64, len(counts))
+		for subProvider, count := range counts {
+			report.SubProviderCounts[subProvider.String()] = count
+		}
+	}
+	return report
+}
+
+func (client *Client) publishStatsReport(report StatsReport) {
+	if onStatsReport, _ := client.onStatsReport.Load().(func(StatsReport)); onStatsReport != nil {
+		onStatsReport(report)
+		return
+	}
+	data, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		client.logger.Error("Client - Failure to marshal stats report: %v\n", marshalErr)
+		return
+	}
+	client.logger.Info("Client - Stats: %s\n", data)
+}
+
+func (client *Client) report(stopping chan bool) {
+	previous := client.getStatsReport(StatsReport{})
+	for {
+		interval := time.Duration(atomic.LoadInt64(&client.statsInterval))
+		if interval <= 0 {
+			select {
+			case <-stopping:
+				return
+			case <-time.After(time.Second):
+			}
+			previous = client.getStatsReport(StatsReport{})
+			continue
+		}
+		select {
+		case <-stopping:
+			return
+		case <-time.After(interval):
+		}
+		current := client.getStatsReport(previous)
+		client.publishStatsReport(current)
+		previous = current
+	}
+}
+
+func (client *Client) countEvent(kind eventKind) {
+	atomic.AddUint64(&client.eventCounts[kind], 1)
+}
+
+func (client *Client) countFrame(data []byte) {
+	atomic.AddUint64(&client.bytesReceived, uint64(len(data)))
+	atomic.StoreInt64(&client.lastMessageTime, time.Now().UnixNano())
+}
+
+func (client *Client) updateQueueHighWatermark() {
+	if depth := int64(len(client.readChannel)); depth > atomic.LoadInt64(&client.queueHighWatermark) {
+		atomic.StoreInt64(&client.queueHighWatermark, depth)
+	}
+}
+
+func (client *Client) GetStats() StatsReport {
+	return client.getStatsReport(StatsReport{})
+}
+
+func (client *Client) LogStats() {
+	client.publishStatsReport(client.getStatsReport(StatsReport{}))
+}
@@ -0,0 +1,80 @@
+package intrinio
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+type SubscriptionError struct {
+	Channel string
+	Message string
+	Time    time.Time
+}
+
+func (client *Client) SetOnSubscriptionError(onSubscriptionError func(SubscriptionError)) {
+	client.onSubscriptionError = onSubscriptionError
+}
+
+func isChannelRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || (r == '_') || (r == '.') || (r == '$')
+}
+
+func (client *Client) getWireChannel(symbol string) string {
+	if client.isOptionsClient() {
+		return convertOldContractIdToNew(symbol)
+	}
+	return symbol
+}
+
+func (client *Client) onTextMessage(message string) {
+	client.logger.Warn("Client - Server message: %s\n", message)
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(message, func(r rune) bool { return !isChannelRune(r) }) {
+		tokens[token] = true
+	}
+	now := time.Now()
+	channels := make([]string, 0)
+	client.subscriptionsLock.Lock()
+	for _, symbol := range sortedKeys(client.subscriptions) {
+		if tokens[symbol] || tokens[client.getWireChannel(symbol)] {
+			client.failedSubscriptions[symbol] = message
+			channels = append(channels, symbol)
+		}
+	}
+	client.subscriptionsLock.Unlock()
+	if client.onSubscriptionError == nil {
+		return
+	}
+	if len(channels) == 0 {
+		client.onSubscriptionError(SubscriptionError{Message: message, Time: now})
+	}
+	for _, channel := range channels {
+		client.onSubscriptionError(SubscriptionError{Channel: channel, Message: message, Time: now})
+	}
+}
+
+func (client *Client) GetFailedSubscriptions() map[string]string {
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	failed := make(map[string]string, len(client.failedSubscriptions))
+	for symbol, message := range client.failedSubscriptions {
+		failed[symbol] = message
+	}
+	return failed
+}
+
+func (client *Client) RetryFailedSubscriptions() int {
+	client.subscriptionsLock.Lock()
+	defer client.subscriptionsLock.Unlock()
+	retried := 0
+	for _, symbol := range sortedKeys(client.failedSubscriptions) {
+		if options, ok := client.subscriptions[symbol]; ok {
+			client.writeChannel <- client.composeJoinMsg(symbol, options)
+			retried++
+		}
+	}
+	client.failedSubscriptions = make(map[string]string)
+	client.logger.Info("Client - Retried %d failed channels\n", retried)
+	return retried
+}
@@ -0,0 +1,46 @@
+package intrinio
+
+import (
+	"sync"
+)
+
+const SYMBOL_TABLE_MAX_SIZE int = 1 << 20
+
+type symbolTable struct {
+	lock   sync.RWMutex
+	values map[string]string
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{values: make(map[string]string)}
+}
+
+func bytesToString(bytes []byte) string {
+	return string(bytes)
+}
+
+func (table *symbolTable) get(key []byte, convert func([]byte) string) string {
+	if table == nil {
+		return convert(key)
+	}
+	table.lock.RLock()
+	value, ok := table.values[string(key)]
+	table.lock.RUnlock()
+	if ok {
+		return value
+	}
+	value = convert(key)
+	table.lock.Lock()
+	if len(table.values) < SYMBOL_TABLE_MAX_SIZE {
+		table.values[string(key)] = value
+	}
+	table.lock.Unlock()
+	return value
+}
+
+func (config Config) getSymbolTable() *symbolTable {
+	if !config.InternSymbols {
+		return nil
+	}
+	return newSymbolTable()
+}
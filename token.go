@@ -0,0 +1,78 @@
+package intrinio
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	TOKEN_REFRESH_AGE   time.Duration = 20 * time.Hour
+	TOKEN_REFRESH_RETRY time.Duration = time.Minute
+)
+
+type AuthFailureKind string
+
+const (
+	AUTH_FAILURE_UNAUTHORIZED AuthFailureKind = "UNAUTHORIZED"
+	AUTH_FAILURE_RATE_LIMITED AuthFailureKind = "RATE_LIMITED"
+	AUTH_FAILURE_SERVER       AuthFailureKind = "SERVER"
+	AUTH_FAILURE_NETWORK      AuthFailureKind = "NETWORK"
+)
+
+type AuthFailure struct {
+	Kind       AuthFailureKind
+	StatusCode int
+	Err        error
+	Time       time.Time
+}
+
+func (client *Client) SetOnAuthFailure(onAuthFailure func(AuthFailure)) {
+	client.onAuthFailure = onAuthFailure
+}
+
+func getAuthFailureKind(statusCode int) AuthFailureKind {
+	switch {
+	case statusCode == 0:
+		return AUTH_FAILURE_NETWORK
+	case (statusCode == http.StatusUnauthorized) || (statusCode == http.StatusForbidden):
+		return AUTH_FAILURE_UNAUTHORIZED
+	case statusCode == http.StatusTooManyRequests:
+		return AUTH_FAILURE_RATE_LIMITED
+	}
+	return AUTH_FAILURE_SERVER
+}
+
+func (client *Client) reportAuthFailure(statusCode int, err error) {
+	if client.onAuthFailure != nil {
+		client.onAuthFailure(AuthFailure{
+			Kind:       getAuthFailureKind(statusCode),
+			StatusCode: statusCode,
+			Err:        err,
+			Time:       time.Now(),
+		})
+	}
+}
+
+func (client *Client) refreshToken(stopping chan bool) {
+	refreshAge := client.getConfig().getTokenRefreshAge()
+	if refreshAge <= 0 {
+		return
+	}
+	for {
+		wait := refreshAge - time.Since(client.tokenUpdateTime)
+		if wait <= 0 {
+			wait = TOKEN_REFRESH_RETRY
+		}
+		select {
+		case <-stopping:
+			return
+		case <-time.After(wait):
+			if time.Since(client.tokenUpdateTime) >= refreshAge {
+				client.logger.Info("Client - Refreshing token")
+				if refreshErr := client.trySetToken(); refreshErr != nil {
+					client.logger.Warn("Client - Failure to refresh token, retrying in %v\n", TOKEN_REFRESH_RETRY)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package intrinio
+
+import (
+	"sync"
+)
+
+const TRADE_SIZE_BUCKET_COUNT int = 8
+
+var TradeSizeBucketBounds [TRADE_SIZE_BUCKET_COUNT - 1]uint32 = [TRADE_SIZE_BUCKET_COUNT - 1]uint32{1, 5, 10, 25, 50, 100, 500}
+
+type TradeSizeStats struct {
+	ContractId   string
+	TradeCount   uint64
+	WindowCount  int
+	AverageSize  float64
+	MaxSize      uint32
+	Distribution [TRADE_SIZE_BUCKET_COUNT]uint32
+}
+
+type TradeSizeConfig struct {
+	WindowSize int
+	MinTrades  int
+}
+
+var DefaultTradeSizeConfig TradeSizeConfig = TradeSizeConfig{
+	WindowSize: 100,
+	MinTrades:  10,
+}
+
+type tradeSizeState struct {
+	sizes      []uint32
+	next       int
+	sum        uint64
+	tradeCount uint64
+}
+
+type TradeSizeTracker struct {
+	config TradeSizeConfig
+	lock   sync.Mutex
+	states map[string]*tradeSizeState
+}
+
+func getTradeSizeBucket(size uint32) int {
+	for i, bound := range TradeSizeBucketBounds {
+		if size <= bound {
+			return i
+		}
+	}
+	return TRADE_SIZE_BUCKET_COUNT - 1
+}
+
+func NewTradeSizeTracker(config TradeSizeConfig) *TradeSizeTracker {
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultTradeSizeConfig.WindowSize
+	}
+	if config.MinTrades <= 0 {
+		config.MinTrades = DefaultTradeSizeConfig.MinTrades
+	}
+	if config.MinTrades > config.WindowSize {
+		config.MinTrades = config.WindowSize
+	}
+	return &TradeSizeTracker{
+		config: config,
+		states: make(map[string]*tradeSizeState),
+	}
+}
+
+func (tracker *TradeSizeTracker) OnOptionTrade(trade OptionTrade) {
+	tracker.lock.Lock()
+	tracker.observe(trade.ContractId, trade.Size)
+	tracker.lock.Unlock()
+}
+
+func (tracker *TradeSizeTracker) observe(contractId string, size uint32) {
+	state, ok := tracker.states[contractId]
+	if !ok {
+		state = &tradeSizeState{sizes: make([]uint32, 0, tracker.config.WindowSize)}
+		tracker.states[contractId] = state
+	}
+	if len(state.sizes) < tracker.config.WindowSize {
+		state.sizes = append(state.sizes, size)
+	} else {
+		state.sum -= uint64(state.sizes[state.next])
+		state.sizes[state.next] = size
+		state.next = (state.next + 1) % tracker.config.WindowSize
+	}
+	state.sum += uint64(size)
+	state.tradeCount++
+}
+
+func (tracker *TradeSizeTracker) getSizeRatio(contractId string, size uint32) (float64, bool) {
+	state, ok := tracker.states[contractId]
+	if !ok || (len(state.sizes) < tracker.config.MinTrades) || (state.sum == 0) {
+		return 0.0, false
+	}
+	return float64(size) * float64(len(state.sizes)) / float64(state.sum), true
+}
+
+func (tracker *TradeSizeTracker) GetSizeRatio(contractId string, size uint32) (float64, bool) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.getSizeRatio(contractId, size)
+}
+
+func (tracker *TradeSizeTracker) GetStats(contractId string) (TradeSizeStats, bool) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	state, ok := tracker.states[contractId]
+	if !ok {
+		return TradeSizeStats{}, false
+	}
+	stats := TradeSizeStats{
+		ContractId:  contractId,
+		TradeCount:  state.tradeCount,
+		WindowCount: len(state.sizes),
+		AverageSize: float64(state.sum) / float64(len(state.sizes)),
+	}
+	for _, size := range state.sizes {
+		if size > stats.MaxSize {
+			stats.MaxSize = size
+		}
+		stats.Distribution[getTradeSizeBucket(size)]++
+	}
+	return stats, true
+}
+
+func (tracker *TradeSizeTracker) FilterOptionTrades(minRatio float64, onTrade func(OptionTrade)) func(OptionTrade) {
+	return func(trade OptionTrade) {
+		tracker.lock.Lock()
+		ratio, ok := tracker.getSizeRatio(trade.ContractId, trade.Size)
+		tracker.observe(trade.ContractId, trade.Size)
+		tracker.lock.Unlock()
+		if ok && (ratio >= minRatio) {
+			onTrade(trade)
+		}
+	}
+}
+
+func (tracker *TradeSizeTracker) FilterUnusualActivity(minRatio float64, onUnusualActivity func(OptionUnusualActivity)) func(OptionUnusualActivity) {
+	return func(ua OptionUnusualActivity) {
+		if ratio, ok := tracker.GetSizeRatio(ua.ContractId, ua.TotalSize); ok && (ratio >= minRatio) {
+			onUnusualActivity(ua)
+		}
+	}
+}
+
+func (tracker *TradeSizeTracker) ResetSession() {
+	tracker.lock.Lock()
+	tracker.states = make(map[string]*tradeSizeState)
+	tracker.lock.Unlock()
+}
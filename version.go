@@ -0,0 +1,25 @@
+package intrinio
+
+import (
+	"net/http"
+	"strings"
+)
+
+const SDK_VERSION string = "2.1.0"
+const SDK_NAME string = "IntrinioRealtimeGoSDK"
+
+func GetClientInformation(appIdentifier string) string {
+	clientInformation := SDK_NAME + "v" + SDK_VERSION
+	if appIdentifier = strings.TrimSpace(appIdentifier); appIdentifier != "" {
+		clientInformation = clientInformation + " " + appIdentifier
+	}
+	return clientInformation
+}
+
+func getRequestHeader(appIdentifier string, headers map[string]string) http.Header {
+	header := http.Header{"Client-Information": {GetClientInformation(appIdentifier)}}
+	for key, value := range headers {
+		header.Set(key, value)
+	}
+	return header
+}